@@ -0,0 +1,636 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log-parser/event"
+	"log-parser/export"
+	"log-parser/match"
+	"log-parser/parser"
+	"log-parser/report"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	dateLayout = "02/01/2006 15:04"
+)
+
+type (
+	matchRow struct {
+		Game       string         `json:"game"`
+		Map        string         `json:"map"`
+		GameType   match.GameType `json:"game_type"`
+		Duration   event.GameTime `json:"duration"`
+		StartTime  *time.Time     `json:"start_time,omitempty"`
+		TotalKills int            `json:"total_kills"`
+		Players    []string       `json:"players"`
+		ExitReason string         `json:"exit_reason"`
+	}
+
+	playerRow struct {
+		Player      string  `json:"player"`
+		Matches     int     `json:"matches"`
+		Frags       int     `json:"frags"`
+		Deaths      int     `json:"deaths"`
+		Suicides    int     `json:"suicides"`
+		WorldDeaths int     `json:"world_deaths"`
+		Score       int     `json:"score"`
+		KDRatio     float64 `json:"kd_ratio"`
+	}
+
+	weaponRow struct {
+		Means string  `json:"means"`
+		Kills int     `json:"kills"`
+		Share float64 `json:"share"`
+	}
+
+	discrepancyRow struct {
+		Game string `json:"game"`
+		match.Discrepancy
+	}
+
+	validation struct {
+		Source        string           `json:"source"`
+		Matches       int              `json:"matches"`
+		Errors        []string         `json:"errors"`
+		Discrepancies []discrepancyRow `json:"discrepancies"`
+	}
+)
+
+func runReport(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatJSON, formatText, formatNDJSON, formatHTML)
+	follow := fs.Bool("follow", false, "keep reading the log as the server appends to it and report each match once it ends")
+	failOnDiscrepancy := fs.Bool("fail-on-discrepancy", false, "exit with code 3 when a computed score differs from the one reported by the server")
+
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	if opts.format == formatNDJSON && opts.anchored() {
+		return &usageError{err: errors.New("-format ndjson writes each match before the whole log is read, so it cannot anchor matches to the wall clock")}
+	}
+
+	if opts.format == formatHTML && (opts.output == "" || *follow) {
+		return &usageError{err: errors.New("-format html writes a page per match into the directory given by -o, and cannot -follow")}
+	}
+
+	if *follow {
+		if len(opts.inputs) > 1 {
+			return &usageError{err: errors.New("-follow reads a single log")}
+		}
+
+		if opts.anchored() {
+			return &usageError{err: errors.New("-follow reports each match before the log ends, so it cannot anchor matches to the wall clock")}
+		}
+
+		return writeOutput(opts, stdout, func(w io.Writer) error {
+			return followLog(opts, w)
+		})
+	}
+
+	if opts.format == formatNDJSON {
+		var logs []parsedLog
+		err := writeOutput(opts, stdout, func(w io.Writer) (err error) {
+			logs, err = streamLogs(opts, w)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if *failOnDiscrepancy {
+			return checkDiscrepancies(logs)
+		}
+
+		return nil
+	}
+
+	now := time.Now()
+
+	logs, err := loadLogs(opts)
+	if err != nil {
+		return err
+	}
+
+	r := report.New(now.UTC())
+	for _, parsed := range logs {
+		r.Add(parsed.path, parsed.matches)
+	}
+
+	if opts.format == formatHTML {
+		if err := r.WriteHTML(opts.output); err != nil {
+			return err
+		}
+
+		if *failOnDiscrepancy {
+			return checkDiscrepancies(logs)
+		}
+
+		return nil
+	}
+
+	err = writeOutput(opts, stdout, func(w io.Writer) error {
+		if opts.format == formatJSON {
+			return r.Write(w)
+		}
+
+		for _, source := range r.Metadata.Sources {
+			if len(r.Metadata.Sources) > 1 {
+				fmt.Fprintf(w, "Log %s\n", source)
+			}
+
+			matches := slices.DeleteFunc(slices.Clone(r.Matches), func(matchReport report.MatchReport) bool {
+				return matchReport.Source != source
+			})
+
+			if err := writeReport(w, matches, opts.noChat); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "reports generated in %d ms\n", time.Since(now).Milliseconds())
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if *failOnDiscrepancy {
+		return checkDiscrepancies(logs)
+	}
+
+	return nil
+}
+
+// checkDiscrepancies fails with errInvalid when a computed score differs from
+// the reported one in any of the matches.
+func checkDiscrepancies(logs []parsedLog) error {
+	discrepancies := 0
+	for _, parsed := range logs {
+		for _, gameMatch := range parsed.matches {
+			if len(gameMatch.Discrepancies()) > 0 {
+				discrepancies++
+			}
+		}
+	}
+
+	if discrepancies > 0 {
+		return fmt.Errorf("%w: computed scores differ from the reported ones in %d matches", errInvalid, discrepancies)
+	}
+
+	return nil
+}
+
+// writeReport writes the report of matches as text, one section per part of
+// the report, each listing the matches it applies to.
+func writeReport(w io.Writer, matches []report.MatchReport, noChat bool) error {
+	reportTime := time.Now().Format(dateLayout)
+
+	fmt.Fprintf(w, "Matches Report - %v\n", reportTime)
+	if err := writeJSON(w, byGame(matches, func(matchReport report.MatchReport) (*match.Match, bool) {
+		return matchReport.Match, true
+	})); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Deaths by Death cause - %v\n", reportTime)
+	if err := writeJSON(w, byGame(matches, func(matchReport report.MatchReport) (match.Summary, bool) {
+		return matchReport.Summary, true
+	})); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Kill Matrix - %v\n", reportTime)
+	for _, matchReport := range matches {
+		fmt.Fprintf(w, "\n%s\n", matchReport.Game)
+		if err := matchReport.Match.WriteKillMatrix(w); err != nil {
+			return fmt.Errorf("writing kill matrix: %w", err)
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Teams - %v\n", reportTime)
+	if err := writeJSON(w, byGame(matches, func(matchReport report.MatchReport) ([]match.TeamSummary, bool) {
+		return matchReport.Teams, matchReport.Teams != nil
+	})); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Item Control - %v\n", reportTime)
+	if err := writeJSON(w, byGame(matches, func(matchReport report.MatchReport) ([]match.ItemControl, bool) {
+		return matchReport.ItemControl, true
+	})); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Capture the Flag - %v\n", reportTime)
+	if err := writeJSON(w, byGame(matches, func(matchReport report.MatchReport) (*match.CTFSummary, bool) {
+		return matchReport.CTF, matchReport.CTF != nil
+	})); err != nil {
+		return err
+	}
+
+	if !noChat {
+		fmt.Fprintf(w, "Chat - %v\n", reportTime)
+		for _, matchReport := range matches {
+			if len(matchReport.Match.Chat) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(w, "\n%s\n", matchReport.Game)
+			if err := matchReport.Match.WriteTranscript(w); err != nil {
+				return fmt.Errorf("writing chat transcript: %w", err)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Score Discrepancies - %v\n", reportTime)
+
+	return writeJSON(w, byGame(matches, func(matchReport report.MatchReport) ([]match.Discrepancy, bool) {
+		return matchReport.Discrepancies, len(matchReport.Discrepancies) > 0
+	}))
+}
+
+// byGame keys a part of the report of each match by its game, leaving out the
+// matches the part does not apply to.
+func byGame[T any](matches []report.MatchReport, part func(report.MatchReport) (T, bool)) []map[string]T {
+	parts := make([]map[string]T, 0, len(matches))
+	for _, matchReport := range matches {
+		if value, ok := part(matchReport); ok {
+			parts = append(parts, map[string]T{matchReport.Game: value})
+		}
+	}
+
+	return parts
+}
+
+func followLog(opts *options, w io.Writer) error {
+	parserOpts, err := opts.parserOptions()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	path := opts.inputs[0]
+
+	// the stream is written as the parser produces each match, so the matches
+	// sent on by the follower are only drained.
+	if opts.format == formatNDJSON {
+		parserOpts = append(parserOpts, parser.WithMatchObserver(newEventStream(w).observer(opts, path)))
+	}
+
+	follower, err := parser.Follow(ctx, path, parserOpts...)
+	if err != nil {
+		return err
+	}
+
+	for gameMatch := range follower.Matches() {
+		if !opts.keep(gameMatch) || opts.format == formatNDJSON {
+			continue
+		}
+
+		r := report.New(time.Now().UTC())
+		r.Add(path, []*match.Match{gameMatch})
+
+		if opts.format == formatText {
+			err = writeReport(w, r.Matches, opts.noChat)
+		} else {
+			err = r.Write(w)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return follower.Err()
+}
+
+func runMatches(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatText, formatJSON)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	logs, err := loadLogs(opts)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]matchRow, 0)
+	for _, parsed := range logs {
+		for _, gameMatch := range parsed.matches {
+			rows = append(rows, matchRow{
+				Game:       gameKey(logs, parsed, gameMatch),
+				Map:        gameMatch.Settings.MapName,
+				GameType:   gameMatch.Settings.GameType,
+				Duration:   gameMatch.Duration,
+				StartTime:  gameMatch.StartTime,
+				TotalKills: gameMatch.TotalKills,
+				Players:    gameMatch.Players,
+				ExitReason: gameMatch.ExitReason,
+			})
+		}
+	}
+
+	return writeOutput(opts, stdout, func(w io.Writer) error {
+		if opts.format == formatJSON {
+			return writeJSON(w, rows)
+		}
+
+		return writeTable(w, []string{"GAME", "MAP", "TYPE", "START", "DURATION", "KILLS", "PLAYERS", "EXIT"}, len(rows), func(i int) []any {
+			row := rows[i]
+
+			start := "-"
+			if row.StartTime != nil {
+				start = row.StartTime.Format(time.DateTime)
+			}
+
+			return []any{row.Game, row.Map, row.GameType, start, row.Duration, row.TotalKills, len(row.Players), row.ExitReason}
+		})
+	})
+}
+
+func runPlayers(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatText, formatJSON)
+	player := fs.String("player", "", "only report the player with this name")
+
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	logs, err := loadLogs(opts)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*playerRow)
+	for _, parsed := range logs {
+		for _, gameMatch := range parsed.matches {
+			for _, name := range gameMatch.Players {
+				if *player != "" && name != *player {
+					continue
+				}
+
+				row, ok := byName[name]
+				if !ok {
+					row = &playerRow{Player: name}
+					byName[name] = row
+				}
+
+				row.Matches++
+				if stats, ok := gameMatch.Stats[name]; ok {
+					row.Frags += stats.Frags
+					row.Deaths += stats.Deaths
+					row.Suicides += stats.Suicides
+					row.WorldDeaths += stats.WorldDeaths
+					row.Score += stats.Score
+				}
+			}
+		}
+	}
+
+	rows := make([]playerRow, 0, len(byName))
+	for _, row := range byName {
+		row.KDRatio = float64(row.Frags)
+		if row.Deaths > 0 {
+			row.KDRatio = float64(row.Frags) / float64(row.Deaths)
+		}
+
+		rows = append(rows, *row)
+	}
+
+	slices.SortFunc(rows, func(a, b playerRow) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+
+		if a.Frags != b.Frags {
+			return b.Frags - a.Frags
+		}
+
+		return strings.Compare(a.Player, b.Player)
+	})
+
+	return writeOutput(opts, stdout, func(w io.Writer) error {
+		if opts.format == formatJSON {
+			return writeJSON(w, rows)
+		}
+
+		return writeTable(w, []string{"PLAYER", "MATCHES", "FRAGS", "DEATHS", "SUICIDES", "WORLD", "SCORE", "K/D"}, len(rows), func(i int) []any {
+			row := rows[i]
+
+			return []any{row.Player, row.Matches, row.Frags, row.Deaths, row.Suicides, row.WorldDeaths, row.Score, fmt.Sprintf("%.2f", row.KDRatio)}
+		})
+	})
+}
+
+func runWeapons(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatText, formatJSON)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	logs, err := loadLogs(opts)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	byMeans := make(map[string]int)
+	for _, parsed := range logs {
+		for _, gameMatch := range parsed.matches {
+			for means, kills := range gameMatch.KillsByMeans {
+				byMeans[means] += kills
+				total += kills
+			}
+		}
+	}
+
+	rows := make([]weaponRow, 0, len(byMeans))
+	for means, kills := range byMeans {
+		rows = append(rows, weaponRow{Means: means, Kills: kills, Share: float64(kills) / float64(total)})
+	}
+
+	slices.SortFunc(rows, func(a, b weaponRow) int {
+		if a.Kills != b.Kills {
+			return b.Kills - a.Kills
+		}
+
+		return strings.Compare(a.Means, b.Means)
+	})
+
+	return writeOutput(opts, stdout, func(w io.Writer) error {
+		if opts.format == formatJSON {
+			return writeJSON(w, rows)
+		}
+
+		return writeTable(w, []string{"MEANS", "KILLS", "SHARE"}, len(rows), func(i int) []any {
+			row := rows[i]
+
+			return []any{row.Means, row.Kills, fmt.Sprintf("%.1f%%", row.Share*100)}
+		})
+	})
+}
+
+func runExport(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatCSV, formatTSV)
+
+	tableNames := make([]string, 0)
+	for _, table := range export.Tables() {
+		tableNames = append(tableNames, string(table))
+	}
+	tableName := fs.String("table", string(export.TableMatches), "table to export: "+strings.Join(tableNames, ", "))
+
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	table, err := export.ParseTable(*tableName)
+	if err != nil {
+		return &usageError{err: err}
+	}
+
+	logs, err := loadLogs(opts)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(opts, stdout, func(w io.Writer) error {
+		writer := export.NewCSVWriter(w, table)
+		if opts.format == formatTSV {
+			writer = export.NewTSVWriter(w, table)
+		}
+
+		for _, parsed := range logs {
+			if err := writer.Write(parsed.path, parsed.matches); err != nil {
+				return err
+			}
+		}
+
+		return writer.Flush()
+	})
+}
+
+// runValidate parses the logs leniently, so every failing line is reported,
+// and fails with errInvalid when any line failed or any computed score
+// differs from the reported one.
+func runValidate(cmd command, args []string, stdout, stderr io.Writer) error {
+	fs, opts := newFlagSet(cmd, stderr, formatText, formatJSON)
+	if err := parseFlags(fs, opts, args); err != nil {
+		return err
+	}
+
+	logs, err := loadLogs(opts, parser.WithErrorMode(parser.Lenient))
+	if err != nil {
+		return err
+	}
+
+	errs, discrepancies := 0, 0
+	validations := make([]validation, 0, len(logs))
+	for _, parsed := range logs {
+		v := validation{
+			Source:        parsed.path,
+			Matches:       len(parsed.matches),
+			Errors:        make([]string, 0, len(parsed.errs)),
+			Discrepancies: make([]discrepancyRow, 0),
+		}
+
+		for _, parseErr := range parsed.errs {
+			v.Errors = append(v.Errors, parseErr.Error())
+		}
+
+		for _, gameMatch := range parsed.matches {
+			for _, discrepancy := range gameMatch.Discrepancies() {
+				v.Discrepancies = append(v.Discrepancies, discrepancyRow{
+					Game:        gameKey(logs, parsed, gameMatch),
+					Discrepancy: discrepancy,
+				})
+			}
+		}
+
+		errs += len(v.Errors)
+		discrepancies += len(v.Discrepancies)
+		validations = append(validations, v)
+	}
+
+	err = writeOutput(opts, stdout, func(w io.Writer) error {
+		if opts.format == formatJSON {
+			return writeJSON(w, validations)
+		}
+
+		for _, v := range validations {
+			fmt.Fprintf(w, "%s: %d matches, %d parse errors, %d score discrepancies\n", v.Source, v.Matches, len(v.Errors), len(v.Discrepancies))
+
+			for _, parseErr := range v.Errors {
+				fmt.Fprintf(w, "  %s\n", parseErr)
+			}
+
+			for _, d := range v.Discrepancies {
+				fmt.Fprintf(w, "  %s: %s computed %d, reported %d (%+d)\n", d.Game, d.Player, d.Computed, d.Reported, d.Delta)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if errs > 0 || discrepancies > 0 {
+		return fmt.Errorf("%w: %d parse errors, %d score discrepancies", errInvalid, errs, discrepancies)
+	}
+
+	return nil
+}
+
+// gameKey names a match as game_N, prefixed by the path of its log when more
+// than one log is read.
+func gameKey(logs []parsedLog, parsed parsedLog, gameMatch *match.Match) string {
+	if len(logs) > 1 {
+		return fmt.Sprintf("%s:game_%d", parsed.path, gameMatch.Index)
+	}
+
+	return fmt.Sprintf("game_%d", gameMatch.Index)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	output, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshalling json output: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(output))
+
+	return err
+}
+
+func writeTable(w io.Writer, header []string, n int, row func(i int) []any) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for i := 0; i < n; i++ {
+		values := row(i)
+
+		cells := make([]string, len(values))
+		for j, value := range values {
+			cells[j] = fmt.Sprint(value)
+		}
+
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
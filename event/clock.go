@@ -0,0 +1,47 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+type (
+	// GameTime is the game clock printed at the start of every log line,
+	// counted from when the server started the map.
+	GameTime time.Duration
+)
+
+func NewGameTime(minutes, seconds int) GameTime {
+	return GameTime(time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+}
+
+func (t GameTime) Duration() time.Duration {
+	return time.Duration(t)
+}
+
+func (t GameTime) Minutes() float64 {
+	return time.Duration(t).Minutes()
+}
+
+func (t GameTime) String() string {
+	seconds := int(time.Duration(t) / time.Second)
+
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func (t GameTime) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *GameTime) UnmarshalText(text []byte) error {
+	var minutes, seconds int
+
+	_, err := fmt.Sscanf(string(text), "%d:%d", &minutes, &seconds)
+	if err != nil {
+		return fmt.Errorf("invalid game time %q", text)
+	}
+
+	*t = NewGameTime(minutes, seconds)
+
+	return nil
+}
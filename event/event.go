@@ -0,0 +1,211 @@
+package event
+
+import (
+	"strconv"
+)
+
+const (
+	TypeInitGame         = "init_game"
+	TypeClientConnect    = "client_connect"
+	TypeUserinfo         = "userinfo"
+	TypeClientBegin      = "client_begin"
+	TypeClientDisconnect = "client_disconnect"
+	TypeKill             = "kill"
+	TypeItem             = "item"
+	TypeSay              = "say"
+	TypeExit             = "exit"
+	TypeTeamScore        = "team_score"
+	TypeScore            = "score"
+	TypeShutdownGame     = "shutdown_game"
+	TypeRestart          = "restart"
+)
+
+type (
+	// Event is a log line turned into what happened in the game, at the game
+	// time and line number it was logged at.
+	Event interface {
+		Type() string
+		Time() GameTime
+		LineNumber() int
+		RawLine() string
+	}
+
+	Header struct {
+		Line int      `json:"line"`
+		At   GameTime `json:"at"`
+		Raw  string   `json:"-"`
+	}
+
+	InitGameEvent struct {
+		Header
+		Settings map[string]string `json:"settings"`
+	}
+
+	ClientConnectEvent struct {
+		Header
+		ClientID int `json:"client_id"`
+	}
+
+	// UserinfoEvent is a client changing its user info, which carries the
+	// name of the player and, in team games, the team it is on.
+	UserinfoEvent struct {
+		Header
+		ClientID int               `json:"client_id"`
+		Name     string            `json:"name"`
+		Info     map[string]string `json:"info"`
+	}
+
+	ClientBeginEvent struct {
+		Header
+		ClientID int `json:"client_id"`
+	}
+
+	ClientDisconnectEvent struct {
+		Header
+		ClientID int `json:"client_id"`
+	}
+
+	KillEvent struct {
+		Header
+		KillerID int    `json:"killer_id"`
+		VictimID int    `json:"victim_id"`
+		MeansID  int    `json:"means_id"`
+		Killer   string `json:"killer"`
+		Victim   string `json:"victim"`
+		Means    string `json:"means"`
+	}
+
+	ItemEvent struct {
+		Header
+		ClientID int    `json:"client_id"`
+		Item     string `json:"item"`
+	}
+
+	// SayEvent is a chat message. The log does not tell who said it apart from
+	// the name at the start of Text, e.g. "Oootsimo: team red".
+	SayEvent struct {
+		Header
+		Team bool   `json:"team"`
+		Text string `json:"text"`
+	}
+
+	ExitEvent struct {
+		Header
+		Reason string `json:"reason"`
+	}
+
+	TeamScoreEvent struct {
+		Header
+		Red  int `json:"red"`
+		Blue int `json:"blue"`
+	}
+
+	ScoreEvent struct {
+		Header
+		ClientID int    `json:"client_id"`
+		Player   string `json:"player"`
+		Score    int    `json:"score"`
+		Ping     int    `json:"ping"`
+	}
+
+	ShutdownGameEvent struct {
+		Header
+	}
+
+	// RestartEvent is a match cut short by the server restarting, which leaves
+	// a line such as "26  0:00 ----" with no valid game time instead of the
+	// ShutdownGame line.
+	RestartEvent struct {
+		Header
+	}
+)
+
+func (h Header) Time() GameTime {
+	return h.At
+}
+
+func (h Header) LineNumber() int {
+	return h.Line
+}
+
+func (h Header) RawLine() string {
+	return h.Raw
+}
+
+// EndsMatch tells whether the event is the last one of a match.
+func EndsMatch(e Event) bool {
+	switch e.(type) {
+	case *ShutdownGameEvent, *RestartEvent:
+		return true
+	default:
+		return false
+	}
+}
+
+// Team is the team number of the player, which is only present in the user
+// info of team games.
+func (e *UserinfoEvent) Team() (int, bool) {
+	value, ok := e.Info["t"]
+	if !ok {
+		return 0, false
+	}
+
+	team, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return team, true
+}
+
+func (e *InitGameEvent) Type() string {
+	return TypeInitGame
+}
+
+func (e *ClientConnectEvent) Type() string {
+	return TypeClientConnect
+}
+
+func (e *UserinfoEvent) Type() string {
+	return TypeUserinfo
+}
+
+func (e *ClientBeginEvent) Type() string {
+	return TypeClientBegin
+}
+
+func (e *ClientDisconnectEvent) Type() string {
+	return TypeClientDisconnect
+}
+
+func (e *KillEvent) Type() string {
+	return TypeKill
+}
+
+func (e *ItemEvent) Type() string {
+	return TypeItem
+}
+
+func (e *SayEvent) Type() string {
+	return TypeSay
+}
+
+func (e *ExitEvent) Type() string {
+	return TypeExit
+}
+
+func (e *TeamScoreEvent) Type() string {
+	return TypeTeamScore
+}
+
+func (e *ScoreEvent) Type() string {
+	return TypeScore
+}
+
+func (e *ShutdownGameEvent) Type() string {
+	return TypeShutdownGame
+}
+
+func (e *RestartEvent) Type() string {
+	return TypeRestart
+}
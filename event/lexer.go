@@ -0,0 +1,228 @@
+package event
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const gameTimePrefix = `^\s*(\d{1,3}):(\d{2})\s+`
+
+var (
+	gameTimeRe         = regexp.MustCompile(gameTimePrefix)
+	initGameRe         = regexp.MustCompile(gameTimePrefix + `InitGame:\s*(.*)$`)
+	clientConnectRe    = regexp.MustCompile(gameTimePrefix + `ClientConnect:\s+(\d+)`)
+	clientUserInfoRe   = regexp.MustCompile(gameTimePrefix + `ClientUserinfoChanged:\s+(\d+)\s+(.*)$`)
+	clientBeginRe      = regexp.MustCompile(gameTimePrefix + `ClientBegin:\s+(\d+)`)
+	clientDisconnectRe = regexp.MustCompile(gameTimePrefix + `ClientDisconnect:\s+(\d+)`)
+	killRe             = regexp.MustCompile(gameTimePrefix + `Kill:\s+(\d+)\s+(\d+)\s+(\d+):\s+(.+?)\skilled\s(.+?)\sby\s(\S+)$`)
+	itemRe             = regexp.MustCompile(gameTimePrefix + `Item:\s+(\d+)\s+(\S+)`)
+	sayRe              = regexp.MustCompile(gameTimePrefix + `(say|sayteam):\s+(.*)$`)
+	exitRe             = regexp.MustCompile(gameTimePrefix + `Exit:\s+(.*)$`)
+	teamScoreRe        = regexp.MustCompile(gameTimePrefix + `red:(-?\d+)\s+blue:(-?\d+)`)
+	scoreRe            = regexp.MustCompile(gameTimePrefix + `score:\s+(-?\d+)\s+ping:\s+(\d+)\s+client:\s+(\d+)\s+(.*)$`)
+	shutdownGameRe     = regexp.MustCompile(gameTimePrefix + `ShutdownGame:$`)
+	restartRe          = regexp.MustCompile(`^.*\d+\s+0:00`)
+)
+
+var lexers = []struct {
+	re  *regexp.Regexp
+	lex func(header Header, values []string) Event
+}{
+	{re: initGameRe, lex: lexInitGame},
+	{re: clientConnectRe, lex: lexClientConnect},
+	{re: clientUserInfoRe, lex: lexUserinfo},
+	{re: clientBeginRe, lex: lexClientBegin},
+	{re: clientDisconnectRe, lex: lexClientDisconnect},
+	{re: killRe, lex: lexKill},
+	{re: itemRe, lex: lexItem},
+	{re: sayRe, lex: lexSay},
+	{re: exitRe, lex: lexExit},
+	{re: teamScoreRe, lex: lexTeamScore},
+	{re: scoreRe, lex: lexScore},
+	{re: shutdownGameRe, lex: lexShutdownGame},
+}
+
+type (
+	// Scanner reads the events of a log, skipping the lines that carry none.
+	// Like bufio.Scanner, it stops at the first error, reported by Err.
+	Scanner struct {
+		sc    *bufio.Scanner
+		line  int
+		event Event
+	}
+)
+
+// Lex turns the log line at the given line number into its event. It reports
+// false for lines that carry no event.
+func Lex(logLine string, lineNumber int) (Event, bool) {
+	for _, lexer := range lexers {
+		values := lexer.re.FindStringSubmatch(logLine)
+		if values == nil {
+			continue
+		}
+
+		at, _ := ParseGameTime(logLine)
+
+		header := Header{Line: lineNumber, At: at, Raw: logLine}
+		if e := lexer.lex(header, values[3:]); e != nil {
+			return e, true
+		}
+	}
+
+	if restartRe.MatchString(logLine) {
+		return &RestartEvent{Header: Header{Line: lineNumber, Raw: logLine}}, true
+	}
+
+	return nil, false
+}
+
+// ParseGameTime reads the game clock at the start of a log line, whether or
+// not the line carries an event.
+func ParseGameTime(logLine string) (GameTime, bool) {
+	values := gameTimeRe.FindStringSubmatch(logLine)
+	if values == nil {
+		return 0, false
+	}
+
+	minutes, _ := strconv.Atoi(values[1])
+	seconds, _ := strconv.Atoi(values[2])
+
+	return NewGameTime(minutes, seconds), true
+}
+
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{sc: bufio.NewScanner(r)}
+}
+
+// Buffer sets the buffer used to read the lines of the log and the size of
+// the longest line it can read, as bufio.Scanner.Buffer does.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	s.sc.Buffer(buf, max)
+}
+
+func (s *Scanner) Scan() bool {
+	for s.sc.Scan() {
+		s.line++
+
+		if e, ok := Lex(s.sc.Text(), s.line); ok {
+			s.event = e
+			return true
+		}
+	}
+
+	s.event = nil
+
+	return false
+}
+
+func (s *Scanner) Event() Event {
+	return s.event
+}
+
+// Line is the number of the last line read from the log.
+func (s *Scanner) Line() int {
+	return s.line
+}
+
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
+
+func lexInitGame(header Header, values []string) Event {
+	return &InitGameEvent{Header: header, Settings: parseInfo(values[0])}
+}
+
+func lexClientConnect(header Header, values []string) Event {
+	id, _ := strconv.Atoi(values[0])
+
+	return &ClientConnectEvent{Header: header, ClientID: id}
+}
+
+func lexUserinfo(header Header, values []string) Event {
+	id, _ := strconv.Atoi(values[0])
+	info := parseInfo(values[1])
+
+	// user info without a name tells nothing about the player.
+	if info["n"] == "" {
+		return nil
+	}
+
+	return &UserinfoEvent{Header: header, ClientID: id, Name: info["n"], Info: info}
+}
+
+func lexClientBegin(header Header, values []string) Event {
+	id, _ := strconv.Atoi(values[0])
+
+	return &ClientBeginEvent{Header: header, ClientID: id}
+}
+
+func lexClientDisconnect(header Header, values []string) Event {
+	id, _ := strconv.Atoi(values[0])
+
+	return &ClientDisconnectEvent{Header: header, ClientID: id}
+}
+
+func lexKill(header Header, values []string) Event {
+	killerID, _ := strconv.Atoi(values[0])
+	victimID, _ := strconv.Atoi(values[1])
+	meansID, _ := strconv.Atoi(values[2])
+
+	return &KillEvent{
+		Header:   header,
+		KillerID: killerID,
+		VictimID: victimID,
+		MeansID:  meansID,
+		Killer:   values[3],
+		Victim:   values[4],
+		Means:    values[5],
+	}
+}
+
+func lexItem(header Header, values []string) Event {
+	id, _ := strconv.Atoi(values[0])
+
+	return &ItemEvent{Header: header, ClientID: id, Item: values[1]}
+}
+
+func lexSay(header Header, values []string) Event {
+	return &SayEvent{Header: header, Team: values[0] == "sayteam", Text: values[1]}
+}
+
+func lexExit(header Header, values []string) Event {
+	return &ExitEvent{Header: header, Reason: values[0]}
+}
+
+func lexTeamScore(header Header, values []string) Event {
+	red, _ := strconv.Atoi(values[0])
+	blue, _ := strconv.Atoi(values[1])
+
+	return &TeamScoreEvent{Header: header, Red: red, Blue: blue}
+}
+
+func lexScore(header Header, values []string) Event {
+	score, _ := strconv.Atoi(values[0])
+	ping, _ := strconv.Atoi(values[1])
+	id, _ := strconv.Atoi(values[2])
+
+	return &ScoreEvent{Header: header, ClientID: id, Player: values[3], Score: score, Ping: ping}
+}
+
+func lexShutdownGame(header Header, _ []string) Event {
+	return &ShutdownGameEvent{Header: header}
+}
+
+// parseInfo reads the backslash separated key/value pairs of InitGame and
+// ClientUserinfoChanged lines.
+func parseInfo(text string) map[string]string {
+	info := make(map[string]string)
+
+	pairs := strings.Split(strings.TrimPrefix(text, "\\"), "\\")
+	for i := 0; i+1 < len(pairs); i += 2 {
+		info[pairs[i]] = pairs[i+1]
+	}
+
+	return info
+}
@@ -0,0 +1,238 @@
+package event
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name    string
+		logLine string
+		want    Event
+		wantOk  bool
+	}{
+		{
+			name:    "should lex a init game line into its settings",
+			logLine: `  0:00 InitGame: \sv_hostname\Code Miner Server\g_gametype\0\mapname\q3dm17`,
+			want: &InitGameEvent{
+				Settings: map[string]string{"sv_hostname": "Code Miner Server", "g_gametype": "0", "mapname": "q3dm17"},
+			},
+			wantOk: true,
+		},
+		{
+			name:    "should lex a client connect line",
+			logLine: " 20:38 ClientConnect: 2",
+			want:    &ClientConnectEvent{ClientID: 2},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a user info line into the name and info of the player",
+			logLine: ` 20:34 ClientUserinfoChanged: 2 n\Isgalamido\t\1\model\xian/default`,
+			want: &UserinfoEvent{
+				ClientID: 2,
+				Name:     "Isgalamido",
+				Info:     map[string]string{"n": "Isgalamido", "t": "1", "model": "xian/default"},
+			},
+			wantOk: true,
+		},
+		{
+			name:    "should skip a user info line without a name",
+			logLine: ` 20:34 ClientUserinfoChanged: 2 n\\t\0`,
+			wantOk:  false,
+		},
+		{
+			name:    "should lex a client begin line",
+			logLine: " 20:38 ClientBegin: 2",
+			want:    &ClientBeginEvent{ClientID: 2},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a client disconnect line",
+			logLine: " 25:05 ClientDisconnect: 4",
+			want:    &ClientDisconnectEvent{ClientID: 4},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a kill line with names holding spaces",
+			logLine: " 22:06 Kill: 2 3 7: Isgalamido killed Dono da Bola by MOD_ROCKET_SPLASH",
+			want: &KillEvent{
+				KillerID: 2,
+				VictimID: 3,
+				MeansID:  7,
+				Killer:   "Isgalamido",
+				Victim:   "Dono da Bola",
+				Means:    "MOD_ROCKET_SPLASH",
+			},
+			wantOk: true,
+		},
+		{
+			name:    "should lex an item line",
+			logLine: " 20:40 Item: 2 weapon_rocketlauncher",
+			want:    &ItemEvent{ClientID: 2, Item: "weapon_rocketlauncher"},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a say line",
+			logLine: "981:21 say: Oootsimo: team red",
+			want:    &SayEvent{Text: "Oootsimo: team red"},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a sayteam line",
+			logLine: "981:26 sayteam: Isgalamido: cover me",
+			want:    &SayEvent{Team: true, Text: "Isgalamido: cover me"},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex an exit line",
+			logLine: " 10:12 Exit: Capturelimit hit.",
+			want:    &ExitEvent{Reason: "Capturelimit hit."},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a team score line",
+			logLine: " 10:12 red:8  blue:6",
+			want:    &TeamScoreEvent{Red: 8, Blue: 6},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a score line",
+			logLine: " 10:12 score: -3  ping: 4  client: 5 Dono da Bola",
+			want:    &ScoreEvent{ClientID: 5, Player: "Dono da Bola", Score: -3, Ping: 4},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a shutdown game line",
+			logLine: " 20:37 ShutdownGame:",
+			want:    &ShutdownGameEvent{},
+			wantOk:  true,
+		},
+		{
+			name:    "should lex a restart line without a game time",
+			logLine: " 26  0:00 ------------------------------------------------------------",
+			want:    &RestartEvent{},
+			wantOk:  true,
+		},
+		{
+			name:    "should skip a separator line",
+			logLine: "  0:00 ------------------------------------------------------------",
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Lex(tt.logLine, 7)
+			assert.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.Equal(t, 7, got.LineNumber())
+			assert.Equal(t, tt.logLine, got.RawLine())
+			assert.IsType(t, tt.want, got)
+			assert.Equal(t, tt.want.Type(), got.Type())
+		})
+	}
+}
+
+func TestLex_Fields(t *testing.T) {
+	got, ok := Lex(" 22:06 Kill: 2 3 7: Isgalamido killed Dono da Bola by MOD_ROCKET_SPLASH", 12)
+	assert.True(t, ok)
+	assert.Equal(t, &KillEvent{
+		Header: Header{
+			Line: 12,
+			At:   NewGameTime(22, 6),
+			Raw:  " 22:06 Kill: 2 3 7: Isgalamido killed Dono da Bola by MOD_ROCKET_SPLASH",
+		},
+		KillerID: 2,
+		VictimID: 3,
+		MeansID:  7,
+		Killer:   "Isgalamido",
+		Victim:   "Dono da Bola",
+		Means:    "MOD_ROCKET_SPLASH",
+	}, got)
+}
+
+func TestParseGameTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		logLine string
+		want    GameTime
+		wantOk  bool
+	}{
+		{
+			name:    "should read the game time of an event line",
+			logLine: " 20:54 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT",
+			want:    NewGameTime(20, 54),
+			wantOk:  true,
+		},
+		{
+			name:    "should read the game time of a line without an event",
+			logLine: "981:27 ------------------------------------------------------------",
+			want:    NewGameTime(981, 27),
+			wantOk:  true,
+		},
+		{
+			name:    "should not read a game time from a line without one",
+			logLine: "garbage",
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseGameTime(tt.logLine)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScanner(t *testing.T) {
+	log := strings.Join([]string{
+		"  0:00 ------------------------------------------------------------",
+		`  0:00 InitGame: \mapname\q3dm17`,
+		" 20:38 ClientConnect: 2",
+		` 20:38 ClientUserinfoChanged: 2 n\Isgalamido\t\0`,
+		" 20:54 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT",
+		" 21:10 ShutdownGame:",
+	}, "\n")
+
+	sc := NewScanner(strings.NewReader(log))
+
+	var types []string
+	var lines []int
+	for sc.Scan() {
+		types = append(types, sc.Event().Type())
+		lines = append(lines, sc.Event().LineNumber())
+	}
+
+	assert.NoError(t, sc.Err())
+	assert.Equal(t, []string{TypeInitGame, TypeClientConnect, TypeUserinfo, TypeKill, TypeShutdownGame}, types)
+	assert.Equal(t, []int{2, 3, 4, 5, 6}, lines)
+	assert.Equal(t, 6, sc.Line())
+	assert.Nil(t, sc.Event())
+}
+
+func TestUserinfoEvent_Team(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   map[string]string
+		want   int
+		wantOk bool
+	}{
+		{name: "should read the team of the player", info: map[string]string{"t": "2"}, want: 2, wantOk: true},
+		{name: "should tell when there is no team", info: map[string]string{}, wantOk: false},
+		{name: "should tell when the team is not a number", info: map[string]string{"t": "red"}, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := (&UserinfoEvent{Info: tt.info}).Team()
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
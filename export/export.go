@@ -0,0 +1,234 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log-parser/match"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TableMatches Table = "matches"
+	TablePlayers Table = "players"
+	TableKills   Table = "kills"
+)
+
+var tables = map[Table]struct {
+	header []string
+	rows   func(source string, gameMatch *match.Match) [][]string
+}{
+	TableMatches: {
+		header: []string{
+			"source", "game", "map", "game_type", "started_at", "ended_at", "duration", "start_time", "end_time",
+			"total_kills", "players", "exit_reason", "red_score", "blue_score",
+		},
+		rows: matchRows,
+	},
+	TablePlayers: {
+		header: []string{
+			"source", "game", "player_key", "player", "client_id", "team", "frags", "deaths", "suicides", "world_deaths",
+			"score", "reported_score", "kd_ratio", "time_played",
+		},
+		rows: playerRows,
+	},
+	TableKills: {
+		header: []string{
+			"source", "game", "at", "time", "killer_key", "killer_id", "killer", "victim_key", "victim_id", "victim",
+			"means",
+		},
+		rows: killRows,
+	},
+}
+
+type (
+	// Table is one of the normalised tables the matches are exported as: a row
+	// per match, a row per player per match or a row per kill.
+	Table string
+
+	// Writer writes a table as delimiter separated values, with a header row
+	// before the rows of the first call to Write.
+	Writer struct {
+		cw          *csv.Writer
+		table       Table
+		wroteHeader bool
+	}
+)
+
+// Tables lists the tables in the order they are documented.
+func Tables() []Table {
+	return []Table{TableMatches, TablePlayers, TableKills}
+}
+
+// ParseTable reads the name of a table.
+func ParseTable(name string) (Table, error) {
+	table := Table(name)
+	if _, ok := tables[table]; !ok {
+		return "", fmt.Errorf("unknown table %q", name)
+	}
+
+	return table, nil
+}
+
+func NewCSVWriter(w io.Writer, table Table) *Writer {
+	return &Writer{cw: csv.NewWriter(w), table: table}
+}
+
+func NewTSVWriter(w io.Writer, table Table) *Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+
+	return &Writer{cw: cw, table: table}
+}
+
+// Write writes the rows of the matches parsed from the log at source. It can
+// be called once per log to export several logs into one table.
+func (w *Writer) Write(source string, matches []*match.Match) error {
+	table, ok := tables[w.table]
+	if !ok {
+		return fmt.Errorf("unknown table %q", w.table)
+	}
+
+	if !w.wroteHeader {
+		if err := w.cw.Write(table.header); err != nil {
+			return fmt.Errorf("writing the %s header: %w", w.table, err)
+		}
+		w.wroteHeader = true
+	}
+
+	for _, gameMatch := range matches {
+		if err := w.cw.WriteAll(table.rows(source, gameMatch)); err != nil {
+			return fmt.Errorf("writing the %s rows: %w", w.table, err)
+		}
+	}
+
+	return nil
+}
+
+// Flush writes out what is left buffered, and the header when no match was
+// written.
+func (w *Writer) Flush() error {
+	if !w.wroteHeader {
+		if err := w.Write("", nil); err != nil {
+			return err
+		}
+	}
+
+	w.cw.Flush()
+
+	return w.cw.Error()
+}
+
+func matchRows(source string, gameMatch *match.Match) [][]string {
+	redScore, blueScore := "", ""
+	if gameMatch.TeamScore != nil {
+		redScore = strconv.Itoa(gameMatch.TeamScore.Red)
+		blueScore = strconv.Itoa(gameMatch.TeamScore.Blue)
+	}
+
+	return [][]string{{
+		source,
+		strconv.Itoa(gameMatch.Index),
+		gameMatch.Settings.MapName,
+		gameMatch.Settings.GameType.String(),
+		gameMatch.StartedAt.String(),
+		gameMatch.EndedAt.String(),
+		gameMatch.Duration.String(),
+		formatTime(gameMatch.StartTime),
+		formatTime(gameMatch.EndTime),
+		strconv.Itoa(gameMatch.TotalKills),
+		strings.Join(gameMatch.Players, ";"),
+		gameMatch.ExitReason,
+		redScore,
+		blueScore,
+	}}
+}
+
+func playerRows(source string, gameMatch *match.Match) [][]string {
+	rows := make([][]string, 0, len(gameMatch.Players))
+	for _, name := range gameMatch.Players {
+		stats, ok := gameMatch.Stats[name]
+		if !ok {
+			stats = &match.PlayerStats{}
+		}
+
+		key, clientID, team, timePlayed := "", "", "", ""
+		for _, player := range gameMatch.Roster {
+			if player.Name == name {
+				key = strconv.Itoa(player.Key)
+				clientID = strconv.Itoa(player.ID)
+				team = player.Team.String()
+				timePlayed = player.TimePlayed.String()
+				break
+			}
+		}
+
+		reportedScore := ""
+		for _, entry := range gameMatch.Scoreboard {
+			if entry.Player == name {
+				reportedScore = strconv.Itoa(entry.Score)
+				break
+			}
+		}
+
+		rows = append(rows, []string{
+			source,
+			strconv.Itoa(gameMatch.Index),
+			key,
+			name,
+			clientID,
+			team,
+			strconv.Itoa(stats.Frags),
+			strconv.Itoa(stats.Deaths),
+			strconv.Itoa(stats.Suicides),
+			strconv.Itoa(stats.WorldDeaths),
+			strconv.Itoa(stats.Score),
+			reportedScore,
+			strconv.FormatFloat(stats.KDRatio, 'f', 2, 64),
+			timePlayed,
+		})
+	}
+
+	return rows
+}
+
+func killRows(source string, gameMatch *match.Match) [][]string {
+	rows := make([][]string, 0, len(gameMatch.KillFeed))
+	for _, kill := range gameMatch.KillFeed {
+		rows = append(rows, []string{
+			source,
+			strconv.Itoa(gameMatch.Index),
+			kill.At.String(),
+			formatTime(kill.Time),
+			formatKey(kill.KillerKey),
+			strconv.Itoa(kill.KillerID),
+			kill.Killer,
+			formatKey(kill.VictimKey),
+			strconv.Itoa(kill.VictimID),
+			kill.Victim,
+			kill.Means,
+		})
+	}
+
+	return rows
+}
+
+// formatKey leaves the key of the world, or of a slot no player was on,
+// empty.
+func formatKey(key int) string {
+	if key == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(key)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bytes"
+	"log-parser/event"
+	"log-parser/match"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMatch() *match.Match {
+	m := match.NewMatch()
+	m.Index = 2
+	m.Settings = match.NewMatchSettings(map[string]string{"mapname": "q3dm17", "g_gametype": "0"})
+	m.Start(event.NewGameTime(20, 37))
+	m.ConnectClient(2, event.NewGameTime(20, 38))
+	m.SetPlayerName(2, "Isgalamido")
+	m.BeginClient(2, event.NewGameTime(20, 38))
+	m.ConnectClient(3, event.NewGameTime(20, 40))
+	m.SetPlayerName(3, "Dono da Bola")
+	m.BeginClient(3, event.NewGameTime(20, 40))
+	m.AddKill(match.KillEvent{At: event.NewGameTime(21, 7), KillerID: 2, VictimID: 3, Killer: "Isgalamido", Victim: "Dono da Bola", Means: "MOD_ROCKET"})
+	m.AddKill(match.KillEvent{At: event.NewGameTime(21, 42), KillerID: 1022, VictimID: 2, Killer: "<world>", Victim: "Isgalamido", Means: "MOD_TRIGGER_HURT"})
+	m.SetExitReason("Fraglimit hit.")
+	m.AddScore(match.ScoreEntry{Player: "Isgalamido", Score: 0, ClientID: 2})
+	m.Finish(event.NewGameTime(22, 37))
+	m.SetWallClock(time.Date(2024, 3, 9, 21, 0, 0, 0, time.UTC))
+
+	return m
+}
+
+func TestWriter_Write(t *testing.T) {
+	tests := []struct {
+		name  string
+		table Table
+		want  string
+	}{
+		{
+			name:  "should write a row per match",
+			table: TableMatches,
+			want: "source,game,map,game_type,started_at,ended_at,duration,start_time,end_time,total_kills,players,exit_reason,red_score,blue_score\n" +
+				"games.log,2,q3dm17,ffa,20:37,22:37,2:00,2024-03-09T21:20:37Z,2024-03-09T21:22:37Z,2,Isgalamido;Dono da Bola,fraglimit,,\n",
+		},
+		{
+			name:  "should write a row per player per match",
+			table: TablePlayers,
+			want: "source,game,player_key,player,client_id,team,frags,deaths,suicides,world_deaths,score,reported_score,kd_ratio,time_played\n" +
+				"games.log,2,1,Isgalamido,2,free,1,1,0,1,0,0,1.00,1:59\n" +
+				"games.log,2,2,Dono da Bola,3,free,0,1,0,0,0,,0.00,1:57\n",
+		},
+		{
+			name:  "should write a row per kill",
+			table: TableKills,
+			want: "source,game,at,time,killer_key,killer_id,killer,victim_key,victim_id,victim,means\n" +
+				"games.log,2,21:07,2024-03-09T21:21:07Z,1,2,Isgalamido,2,3,Dono da Bola,MOD_ROCKET\n" +
+				"games.log,2,21:42,2024-03-09T21:21:42Z,,1022,<world>,1,2,Isgalamido,MOD_TRIGGER_HURT\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := NewCSVWriter(&buf, tt.table)
+			assert.NoError(t, w.Write("games.log", []*match.Match{newTestMatch()}))
+			assert.NoError(t, w.Flush())
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriter_Write_TSV(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewTSVWriter(&buf, TableKills)
+	assert.NoError(t, w.Write("a.log", []*match.Match{newTestMatch()}))
+	assert.NoError(t, w.Write("b.log", nil))
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, "source\tgame\tat\ttime\tkiller_key\tkiller_id\tkiller\tvictim_key\tvictim_id\tvictim\tmeans\n"+
+		"a.log\t2\t21:07\t2024-03-09T21:21:07Z\t1\t2\tIsgalamido\t2\t3\tDono da Bola\tMOD_ROCKET\n"+
+		"a.log\t2\t21:42\t2024-03-09T21:21:42Z\t\t1022\t<world>\t1\t2\tIsgalamido\tMOD_TRIGGER_HURT\n", buf.String())
+}
+
+func TestWriter_Flush(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewCSVWriter(&buf, TableKills)
+	assert.NoError(t, w.Flush())
+
+	assert.Equal(t, "source,game,at,time,killer_key,killer_id,killer,victim_key,victim_id,victim,means\n", buf.String())
+}
+
+func TestParseTable(t *testing.T) {
+	for _, table := range Tables() {
+		got, err := ParseTable(string(table))
+		assert.NoError(t, err)
+		assert.Equal(t, table, got)
+	}
+
+	_, err := ParseTable("weapons")
+	assert.EqualError(t, err, `unknown table "weapons"`)
+}
@@ -1,61 +1,382 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"log-parser/match"
 	"log-parser/parser"
+	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
-	dateLayout = "02/01/2006 15:04"
+	exitOK      = 0
+	exitError   = 1
+	exitUsage   = 2
+	exitInvalid = 3
+
+	defaultLogPath = "qgames.log"
+
+	formatText   = "text"
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatTSV    = "tsv"
+	formatNDJSON = "ndjson"
+	formatHTML   = "html"
 )
 
+// errInvalid is returned by the commands when the log parsed but did not pass
+// a check, such as the scores validate compares.
+var errInvalid = errors.New("log failed validation")
+
+type (
+	command struct {
+		name    string
+		summary string
+		run     func(cmd command, args []string, stdout, stderr io.Writer) error
+	}
+
+	// usageError is a command line the commands could not make sense of. The
+	// flag package has already told the user what was wrong when err is nil.
+	usageError struct {
+		err error
+	}
+
+	options struct {
+		inputs        []string
+		format        string
+		formats       []string
+		output        string
+		mapName       string
+		gameType      string
+		startTime     string
+		modTime       bool
+		referenceLine int
+		referenceTime string
+		from          string
+		to            string
+		noChat        bool
+	}
+
+	// parsedLog holds the matches parsed from one of the input logs, and the
+	// lines that failed to parse when parsing leniently.
+	parsedLog struct {
+		path    string
+		matches []*match.Match
+		errs    parser.ParseErrors
+	}
+)
+
+var commands = []command{
+	{name: "report", summary: "print the full report of every match (default)", run: runReport},
+	{name: "matches", summary: "list the matches with their map, game type, duration and kills", run: runMatches},
+	{name: "players", summary: "total the stats of each player across the matches", run: runPlayers},
+	{name: "weapons", summary: "count the kills of each means of death across the matches", run: runWeapons},
+	{name: "export", summary: "export a table of the matches, players or kills as CSV or TSV", run: runExport},
+	{name: "validate", summary: "check the log for parse errors and score discrepancies", run: runValidate},
+}
+
 func main() {
-	now := time.Now()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	matches, err := parser.ParseLog("qgames.log")
-	if err != nil {
-		log.Fatal(err)
+// run runs the command named by the first argument, or report when the
+// arguments start with a flag, and tells the exit code of the process.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && slices.Contains([]string{"help", "-h", "-help", "--help"}, args[0]) {
+		usage(stdout)
+		return exitOK
 	}
 
-	n := len(matches)
+	name := "report"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	i := slices.IndexFunc(commands, func(c command) bool {
+		return c.name == name
+	})
+	if i < 0 {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+
+		return exitUsage
+	}
 
-	games := make([]map[string]*match.Match, n)
-	matchSummary := make([]map[string]match.Summary, n)
-	for i := 0; i < n; i++ {
-		key := fmt.Sprintf("game_%d", i+1)
+	err := commands[i].run(commands[i], args, stdout, stderr)
 
-		games[i] = map[string]*match.Match{
-			key: matches[i],
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		if usageErr.err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", name, usageErr.err)
 		}
 
-		matchSummary[i] = map[string]match.Summary{
-			key: {
-				KillsByMeans: matches[i].KillsByMeans,
-			},
+		return exitUsage
+	case errors.Is(err, errInvalid):
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+
+		return exitInvalid
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+
+		return exitError
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: log-parser <command> [flags] [log files]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Reports the matches of Quake III Arena server logs. Commands:")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run log-parser <command> -help for the flags of a command.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Exit codes: 0 success, 1 parsing or writing failed, 2 invalid command line, 3 validation failed.")
+}
+
+func (e *usageError) Error() string {
+	if e.err == nil {
+		return "invalid command line"
+	}
+
+	return e.err.Error()
+}
+
+// newFlagSet registers the flags every command shares. formats lists the
+// output formats the command writes, the first one being the default.
+func newFlagSet(cmd command, stderr io.Writer, formats ...string) (*flag.FlagSet, *options) {
+	opts := &options{formats: formats}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: log-parser %s [flags] [log files]\n\n", cmd.name)
+		fmt.Fprintf(stderr, "%s. Reads %s when no log file is given.\n\nFlags:\n", capitalize(cmd.summary), defaultLogPath)
+		fs.PrintDefaults()
+	}
+
+	fs.Func("log", "path of a Quake III Arena log file; may be repeated", func(path string) error {
+		opts.inputs = append(opts.inputs, path)
+		return nil
+	})
+	fs.StringVar(&opts.format, "format", formats[0], "output format: "+strings.Join(formats, ", "))
+	fs.StringVar(&opts.output, "o", "", "write the output to this file instead of the standard output")
+
+	fs.StringVar(&opts.mapName, "map", "", "only report matches played on this map")
+	fs.StringVar(&opts.gameType, "game-type", "", "only report matches of this game type, e.g. ffa, tdm or ctf")
+
+	fs.StringVar(&opts.startTime, "start-time", "", "wall-clock time the first match of the log started at, e.g. 2024-03-09T21:00:00Z")
+	fs.BoolVar(&opts.modTime, "mod-time", false, "take the modification time of the log file as the wall-clock time of its last line")
+	fs.IntVar(&opts.referenceLine, "reference-line", 0, "number of a log line whose wall-clock time is given by -reference-time")
+	fs.StringVar(&opts.referenceTime, "reference-time", "", "wall-clock time of the line given by -reference-line")
+	fs.StringVar(&opts.from, "from", "", "only report matches started at or after this date or time")
+	fs.StringVar(&opts.to, "to", "", "only report matches started before this date or time")
+
+	fs.BoolVar(&opts.noChat, "no-chat", false, "leave what players said out of the reports")
+
+	return fs, opts
+}
+
+// parseFlags parses the command line of a command and checks the options it
+// shares with the others.
+func parseFlags(fs *flag.FlagSet, opts *options, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return &usageError{}
+	}
+
+	opts.inputs = append(opts.inputs, fs.Args()...)
+	if len(opts.inputs) == 0 {
+		opts.inputs = []string{defaultLogPath}
+	}
+
+	if !slices.Contains(opts.formats, opts.format) {
+		return &usageError{err: fmt.Errorf("unknown format %q, expected one of %s", opts.format, strings.Join(opts.formats, ", "))}
+	}
+
+	if opts.gameType != "" {
+		var gameType match.GameType
+		if err := gameType.UnmarshalText([]byte(opts.gameType)); err != nil {
+			return &usageError{err: err}
 		}
 	}
 
-	matchesOutput, err := json.MarshalIndent(games, "", "    ")
+	return opts.checkAnchor()
+}
+
+// checkAnchor rejects wall-clock flags that would otherwise be ignored: more
+// than one anchor, half of a reference line, or a date range with nothing to
+// tell the dates of the matches by.
+func (o *options) checkAnchor() error {
+	anchors := 0
+	for _, given := range []bool{o.startTime != "", o.modTime, o.referenceLine > 0} {
+		if given {
+			anchors++
+		}
+	}
+
+	switch {
+	case anchors > 1:
+		return &usageError{err: errors.New("-start-time, -mod-time and -reference-line cannot be combined, give one of them")}
+	case o.referenceTime != "" && o.referenceLine <= 0:
+		return &usageError{err: errors.New("-reference-time needs the -reference-line it is the time of")}
+	case o.referenceLine > 0 && o.referenceTime == "":
+		return &usageError{err: errors.New("-reference-line needs its wall-clock time in -reference-time")}
+	case anchors == 0 && (o.from != "" || o.to != ""):
+		return &usageError{err: errors.New("-from and -to need a wall-clock anchor, give -start-time, -mod-time or -reference-line")}
+	}
+
+	return nil
+}
+
+func (o *options) parserOptions() ([]parser.Option, error) {
+	opts := make([]parser.Option, 0)
+	if o.noChat {
+		opts = append(opts, parser.WithoutChat())
+	}
+
+	switch {
+	case o.startTime != "":
+		t, err := parseTime(o.startTime)
+		if err != nil {
+			return nil, &usageError{err: fmt.Errorf("parsing -start-time: %w", err)}
+		}
+
+		opts = append(opts, parser.WithStartTime(t))
+	case o.modTime:
+		opts = append(opts, parser.WithFileModTime())
+	case o.referenceLine > 0:
+		t, err := parseTime(o.referenceTime)
+		if err != nil {
+			return nil, &usageError{err: fmt.Errorf("parsing -reference-time: %w", err)}
+		}
+
+		opts = append(opts, parser.WithReferenceLine(o.referenceLine, t))
+	}
+
+	if o.from != "" || o.to != "" {
+		var fromTime, toTime time.Time
+		var err error
+
+		if o.from != "" {
+			if fromTime, err = parseTime(o.from); err != nil {
+				return nil, &usageError{err: fmt.Errorf("parsing -from: %w", err)}
+			}
+		}
+
+		if o.to != "" {
+			if toTime, err = parseTime(o.to); err != nil {
+				return nil, &usageError{err: fmt.Errorf("parsing -to: %w", err)}
+			}
+		}
+
+		opts = append(opts, parser.WithDateRange(fromTime, toTime))
+	}
+
+	return opts, nil
+}
+
+// anchored tells whether any wall-clock flag was given.
+func (o *options) anchored() bool {
+	return o.startTime != "" || o.modTime || o.referenceLine > 0 || o.from != "" || o.to != ""
+}
+
+// keep tells whether gameMatch passes the -map and -game-type filters.
+func (o *options) keep(gameMatch *match.Match) bool {
+	if o.mapName != "" && !strings.EqualFold(gameMatch.Settings.MapName, o.mapName) {
+		return false
+	}
+
+	if o.gameType != "" {
+		var gameType match.GameType
+		_ = gameType.UnmarshalText([]byte(o.gameType))
+
+		if gameMatch.Settings.GameType != gameType {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadLogs parses every input log, leaving out the matches filtered out.
+func loadLogs(o *options, extra ...parser.Option) ([]parsedLog, error) {
+	opts, err := o.parserOptions()
 	if err != nil {
-		log.Fatalf("marshalling json output: %v", err.Error())
+		return nil, err
 	}
+	opts = append(opts, extra...)
+
+	logs := make([]parsedLog, 0, len(o.inputs))
+	for _, path := range o.inputs {
+		matches, err := parser.ParseLog(path, opts...)
 
-	summaryOutput, err := json.MarshalIndent(matchSummary, "", "    ")
+		var parseErrs parser.ParseErrors
+		if err != nil && !errors.As(err, &parseErrs) {
+			return nil, err
+		}
+
+		logs = append(logs, parsedLog{
+			path: path,
+			matches: slices.DeleteFunc(matches, func(gameMatch *match.Match) bool {
+				return !o.keep(gameMatch)
+			}),
+			errs: parseErrs,
+		})
+	}
+
+	return logs, nil
+}
+
+// writeOutput hands write the -o file, or stdout when none was given.
+func writeOutput(o *options, stdout io.Writer, write func(w io.Writer) error) (err error) {
+	if o.output == "" {
+		return write(stdout)
+	}
+
+	file, err := os.Create(o.output)
 	if err != nil {
-		log.Fatalf("marshalling json output: %v", err.Error())
+		return fmt.Errorf("creating the output file: %w", err)
 	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf("closing the output file: %w", closeErr)
+		}
+	}()
 
-	reportTime := time.Now().Format(dateLayout)
+	return write(file)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
 
-	fmt.Printf("Matches Report - %v\n", reportTime)
-	fmt.Println(string(matchesOutput))
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
 
-	fmt.Printf("Deaths by Death cause - %v\n", reportTime)
-	fmt.Println(string(summaryOutput))
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
 
-	fmt.Printf("reports generated in %d ms", time.Since(now).Milliseconds())
+	return strings.ToUpper(s[:1]) + s[1:]
 }
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log-parser/report"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	threeMatchesLog  = "parser/testfiles/qgames_three_matches.log"
+	completeMatchLog = "parser/testfiles/qgames_complete_match.log"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "should list the commands on help",
+			args:       []string{"--help"},
+			wantCode:   exitOK,
+			wantStdout: "validate",
+		},
+		{
+			name:       "should print the flags of a command on help",
+			args:       []string{"players", "-help"},
+			wantCode:   exitOK,
+			wantStderr: "-player",
+		},
+		{
+			name:       "should fail on an unknown command",
+			args:       []string{"scores"},
+			wantCode:   exitUsage,
+			wantStderr: `unknown command "scores"`,
+		},
+		{
+			name:       "should fail on an unknown flag",
+			args:       []string{"matches", "-verbose"},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined: -verbose",
+		},
+		{
+			name:       "should fail on an unknown format",
+			args:       []string{"weapons", "-format", "xml", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: `unknown format "xml"`,
+		},
+		{
+			name:       "should fail on an unknown game type",
+			args:       []string{"matches", "-game-type", "race", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: `unknown game type "race"`,
+		},
+		{
+			name:       "should fail on a missing log",
+			args:       []string{"matches", "missing.log"},
+			wantCode:   exitError,
+			wantStderr: "reading the log file",
+		},
+		{
+			name:       "should run report when the arguments start with a flag",
+			args:       []string{"-log", threeMatchesLog, "-no-chat"},
+			wantCode:   exitOK,
+			wantStdout: `"match_count": 3`,
+		},
+		{
+			name:       "should write the report as text",
+			args:       []string{"report", "-format", "text", threeMatchesLog},
+			wantCode:   exitOK,
+			wantStdout: "Matches Report",
+		},
+		{
+			name:       "should export a table",
+			args:       []string{"export", "-table", "kills", "-format", "tsv", threeMatchesLog},
+			wantCode:   exitOK,
+			wantStdout: "source\tgame\tat\ttime\tkiller_key\tkiller_id\tkiller\tvictim_key\tvictim_id\tvictim\tmeans\n",
+		},
+		{
+			name:       "should fail on an unknown table",
+			args:       []string{"export", "-table", "weapons", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: `unknown table "weapons"`,
+		},
+		{
+			name:       "should not anchor a streamed report",
+			args:       []string{"report", "-format", "ndjson", "-start-time", "2024-03-09", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "cannot anchor matches to the wall clock",
+		},
+		{
+			name:       "should not anchor a followed report",
+			args:       []string{"report", "-follow", "-start-time", "2024-03-09", "-from", "2024-03-09", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "cannot anchor matches to the wall clock",
+		},
+		{
+			name:       "should need an anchor to filter matches by date",
+			args:       []string{"matches", "-from", "2024-01-01", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "-from and -to need a wall-clock anchor",
+		},
+		{
+			name:       "should need a reference line for a reference time",
+			args:       []string{"matches", "-reference-time", "2024-03-09T21:00:00Z", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "-reference-time needs the -reference-line",
+		},
+		{
+			name:       "should need a reference time for a reference line",
+			args:       []string{"matches", "-reference-line", "3", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "-reference-line needs its wall-clock time",
+		},
+		{
+			name:       "should not take more than one anchor",
+			args:       []string{"matches", "-start-time", "2024-03-09", "-mod-time", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "cannot be combined",
+		},
+		{
+			name:       "should need a directory for the html report",
+			args:       []string{"report", "-format", "html", threeMatchesLog},
+			wantCode:   exitUsage,
+			wantStderr: "into the directory given by -o",
+		},
+		{
+			name:       "should list the matches",
+			args:       []string{"matches", threeMatchesLog},
+			wantCode:   exitOK,
+			wantStdout: "game_3",
+		},
+		{
+			name:       "should validate a log without problems",
+			args:       []string{"validate", completeMatchLog},
+			wantCode:   exitOK,
+			wantStdout: "0 parse errors, 0 score discrepancies",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			assert.Equal(t, tt.wantCode, run(tt.args, &stdout, &stderr), stderr.String())
+			assert.Contains(t, stdout.String(), tt.wantStdout)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
+
+func TestRun_Report(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"report", "-log", threeMatchesLog, "-log", completeMatchLog}, &stdout, &stderr))
+
+	got, err := report.Decode(&stdout)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{threeMatchesLog, completeMatchLog}, got.Metadata.Sources)
+	assert.Equal(t, 4, got.Metadata.MatchCount)
+}
+
+func TestRun_ReportNDJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"report", "-format", "ndjson", threeMatchesLog}, &stdout, &stderr))
+
+	counts := make(map[string]int)
+	var games []int
+	for _, line := range strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n") {
+		var record struct {
+			Type   string `json:"type"`
+			Source string `json:"source"`
+			Game   int    `json:"game"`
+			Killer string `json:"killer"`
+		}
+		if !assert.NoError(t, json.Unmarshal([]byte(line), &record), line) {
+			continue
+		}
+
+		assert.Equal(t, threeMatchesLog, record.Source)
+		counts[record.Type]++
+		if record.Type == "match" {
+			games = append(games, record.Game)
+		}
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, games)
+	assert.Equal(t, 3, counts["init_game"])
+	assert.Equal(t, 15, counts["kill"])
+	assert.True(t, strings.HasPrefix(stdout.String(), `{"type":"init_game","source":"`+threeMatchesLog+`","game":1,`))
+	assert.Contains(t, stdout.String(), `"killer":"<world>"`)
+}
+
+func TestRun_ReportHTML(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "html")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"report", "-format", "html", "-o", dir, threeMatchesLog}, &stdout, &stderr), stderr.String())
+	assert.Empty(t, stdout.String())
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), `<a href="game_3.html">`)
+	assert.FileExists(t, filepath.Join(dir, "game_3.html"))
+}
+
+func TestRun_Matches(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"matches", "-format", "json", threeMatchesLog}, &stdout, &stderr))
+
+	var rows []matchRow
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &rows))
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "game_2", rows[1].Game)
+		assert.Equal(t, "q3dm17", rows[1].Map)
+		assert.Equal(t, 11, rows[1].TotalKills)
+	}
+}
+
+func TestRun_MultipleLogs(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"matches", "-format", "json", "-log", threeMatchesLog, completeMatchLog}, &stdout, &stderr))
+
+	var rows []matchRow
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &rows))
+	if assert.Len(t, rows, 4) {
+		assert.Equal(t, threeMatchesLog+":game_1", rows[0].Game)
+		assert.Equal(t, completeMatchLog+":game_1", rows[3].Game)
+	}
+}
+
+func TestRun_Players(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"players", "-format", "json", "-player", "Isgalamido", threeMatchesLog}, &stdout, &stderr))
+
+	var rows []playerRow
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &rows))
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "Isgalamido", rows[0].Player)
+		assert.Equal(t, 3, rows[0].Matches)
+	}
+}
+
+func TestRun_Weapons(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"weapons", "-format", "json", threeMatchesLog}, &stdout, &stderr))
+
+	var rows []weaponRow
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &rows))
+
+	total := 0
+	for _, row := range rows {
+		total += row.Kills
+	}
+	assert.Equal(t, 15, total)
+}
+
+func TestRun_Output(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "matches.txt")
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"matches", "-o", output, threeMatchesLog}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	written, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(written), "GAME"))
+}
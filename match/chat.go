@@ -0,0 +1,74 @@
+package match
+
+import (
+	"fmt"
+	"io"
+	"log-parser/event"
+	"strings"
+	"time"
+)
+
+const (
+	ChatAll  = "all"
+	ChatTeam = "team"
+)
+
+type (
+	// ChatMessage is a message said by a player in the match. The log does not
+	// print the client slot of the speaker, so ClientID is resolved from the
+	// name and is -1 when no player on a slot has that name.
+	ChatMessage struct {
+		At       event.GameTime `json:"at"`
+		ClientID int            `json:"client_id"`
+		Player   string         `json:"player"`
+		Channel  string         `json:"channel"`
+		Text     string         `json:"text"`
+		Time     *time.Time     `json:"time,omitempty"`
+	}
+)
+
+// Say records a chat message from the text of a say or sayteam line, which
+// is the name of the speaker and the message separated by ": ".
+func (m *Match) Say(channel, text string, at event.GameTime) {
+	m.observe(at)
+
+	message := ChatMessage{At: at, ClientID: -1, Channel: channel}
+
+	// names may contain ": " as well, so the longest name of a connected
+	// player that prefixes the text is taken as the speaker.
+	said := ""
+	for id, player := range m.slots {
+		name := player.alias()
+		if !strings.HasPrefix(text, name+": ") {
+			continue
+		}
+
+		longer := len(name) > len(said)
+		tie := len(name) == len(said) && id < message.ClientID
+		if longer || tie {
+			message.ClientID = id
+			message.Player = player.Name
+			said = name
+		}
+	}
+
+	if message.ClientID == -1 {
+		message.Player, text, _ = strings.Cut(text, ": ")
+	} else {
+		text = strings.TrimPrefix(text, said+": ")
+	}
+
+	message.Text = text
+	m.Chat = append(m.Chat, message)
+}
+
+// WriteTranscript writes the chat of the match, one message per line.
+func (m *Match) WriteTranscript(w io.Writer) error {
+	for _, message := range m.Chat {
+		if _, err := fmt.Fprintf(w, "%s [%s] %s: %s\n", message.At, message.Channel, message.Player, message.Text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
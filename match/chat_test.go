@@ -0,0 +1,61 @@
+package match
+
+import (
+	"bytes"
+	"log-parser/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_Say(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		text    string
+		want    ChatMessage
+	}{
+		{
+			name:    "should resolve the slot of the speaker from the name",
+			channel: ChatAll,
+			text:    "Oootsimo: team red",
+			want:    ChatMessage{ClientID: 2, Player: "Oootsimo", Channel: ChatAll, Text: "team red"},
+		},
+		{
+			name:    "should take the longest name that prefixes the message",
+			channel: ChatTeam,
+			text:    "Dono: da Bola: hi",
+			want:    ChatMessage{ClientID: 4, Player: "Dono: da Bola", Channel: ChatTeam, Text: "hi"},
+		},
+		{
+			name:    "should keep the name of a speaker that is not on a slot",
+			channel: ChatAll,
+			text:    "Zeh: gg",
+			want:    ChatMessage{ClientID: -1, Player: "Zeh", Channel: ChatAll, Text: "gg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+			m.SetPlayerName(2, "Oootsimo")
+			m.SetPlayerName(3, "Dono")
+			m.SetPlayerName(4, "Dono: da Bola")
+
+			m.Say(tt.channel, tt.text, 0)
+
+			assert.Equal(t, []ChatMessage{tt.want}, m.Chat)
+		})
+	}
+}
+
+func TestMatch_WriteTranscript(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Oootsimo")
+	m.SetPlayerName(3, "Isgalamido")
+	m.Say(ChatAll, "Oootsimo: team red", event.NewGameTime(981, 21))
+	m.Say(ChatTeam, "Isgalamido: team blue", event.NewGameTime(981, 26))
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.WriteTranscript(&buf))
+	assert.Equal(t, "981:21 [all] Oootsimo: team red\n981:26 [team] Isgalamido: team blue\n", buf.String())
+}
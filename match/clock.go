@@ -0,0 +1,53 @@
+package match
+
+import (
+	"log-parser/event"
+	"time"
+)
+
+// Clock is the latest game time seen in the match.
+func (m *Match) Clock() event.GameTime {
+	return m.clock
+}
+
+// SetWallClock anchors the game clock of the match to the wall-clock time at
+// which it read 0:00, and fills in the wall-clock time of the match and of the
+// events in its kill feed and chat.
+func (m *Match) SetWallClock(zero time.Time) {
+	m.wallClock = &zero
+
+	startTime, _ := m.WallClock(m.StartedAt)
+	m.StartTime = &startTime
+
+	m.EndTime = nil
+	if m.Done {
+		endTime, _ := m.WallClock(m.EndedAt)
+		m.EndTime = &endTime
+	}
+
+	for i := range m.KillFeed {
+		killTime, _ := m.WallClock(m.KillFeed[i].At)
+		m.KillFeed[i].Time = &killTime
+	}
+
+	for i := range m.Chat {
+		chatTime, _ := m.WallClock(m.Chat[i].At)
+		m.Chat[i].Time = &chatTime
+	}
+}
+
+// WallClock converts a game time of the match to wall-clock time. It reports
+// false when the match was not anchored to the wall clock.
+func (m *Match) WallClock(at event.GameTime) (time.Time, bool) {
+	if m.wallClock == nil {
+		return time.Time{}, false
+	}
+
+	return m.wallClock.Add(at.Duration()), true
+}
+
+// Start marks the game time the match was initialised at.
+func (m *Match) Start(at event.GameTime) {
+	m.StartedAt = at
+	m.observe(at)
+}
@@ -0,0 +1,56 @@
+package match
+
+import (
+	"log-parser/event"
+	"strings"
+)
+
+const (
+	ExitFragLimit    = "fraglimit"
+	ExitTimeLimit    = "timelimit"
+	ExitCaptureLimit = "capturelimit"
+	ExitAborted      = "aborted"
+)
+
+type (
+	// ScoreEntry is a row of the final scoreboard printed by the server when
+	// the match exits.
+	ScoreEntry struct {
+		Player   string `json:"player"`
+		Score    int    `json:"score"`
+		Ping     int    `json:"ping"`
+		ClientID int    `json:"client_id"`
+	}
+)
+
+// SetExitReason records why the match ended from the text of the Exit line,
+// e.g. "Fraglimit hit.".
+func (m *Match) SetExitReason(text string) {
+	reason := strings.ToLower(strings.TrimSpace(text))
+	reason = strings.TrimSuffix(reason, ".")
+	reason = strings.TrimSuffix(reason, " hit")
+
+	m.ExitReason = reason
+}
+
+func (m *Match) AddScore(entry ScoreEntry) {
+	entry.Player = m.playerName(entry.ClientID, entry.Player)
+	m.Scoreboard = append(m.Scoreboard, entry)
+}
+
+// Finish ends the match at the given game time, or at the last game time seen
+// when the clock was reset. A match that ends without an Exit line was
+// aborted.
+func (m *Match) Finish(at event.GameTime) {
+	m.InProgress = false
+	m.Done = true
+
+	m.EndedAt = max(at, m.clock)
+	m.Duration = max(m.EndedAt-m.StartedAt, 0)
+
+	if m.ExitReason == "" {
+		m.ExitReason = ExitAborted
+	}
+
+	m.CloseSessions(at)
+}
@@ -0,0 +1,61 @@
+package match
+
+import (
+	"log-parser/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_Finish(t *testing.T) {
+	tests := []struct {
+		name           string
+		exitReason     string
+		startedAt      event.GameTime
+		lastSeen       event.GameTime
+		finishedAt     event.GameTime
+		wantExitReason string
+		wantEndedAt    event.GameTime
+		wantDuration   event.GameTime
+	}{
+		{
+			name:           "should time the match from its start to its end",
+			exitReason:     "Fraglimit hit.",
+			startedAt:      event.NewGameTime(6, 34),
+			lastSeen:       event.NewGameTime(13, 55),
+			finishedAt:     event.NewGameTime(14, 11),
+			wantExitReason: ExitFragLimit,
+			wantEndedAt:    event.NewGameTime(14, 11),
+			wantDuration:   event.NewGameTime(7, 37),
+		},
+		{
+			name:           "should end the match at the last game time seen when its end has no valid time",
+			startedAt:      event.NewGameTime(20, 37),
+			lastSeen:       event.NewGameTime(26, 9),
+			finishedAt:     0,
+			wantExitReason: ExitAborted,
+			wantEndedAt:    event.NewGameTime(26, 9),
+			wantDuration:   event.NewGameTime(5, 32),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+			m.Start(tt.startedAt)
+			kill := KillEvent{At: tt.lastSeen, KillerID: 2, VictimID: 3, Killer: "Zeh", Victim: "Mal", Means: "MOD_RAILGUN"}
+			m.AddKill(kill)
+			if tt.exitReason != "" {
+				m.SetExitReason(tt.exitReason)
+			}
+
+			m.Finish(tt.finishedAt)
+
+			assert.True(t, m.Done)
+			assert.Equal(t, tt.wantExitReason, m.ExitReason)
+			assert.Equal(t, tt.startedAt, m.StartedAt)
+			assert.Equal(t, tt.wantEndedAt, m.EndedAt)
+			assert.Equal(t, tt.wantDuration, m.Duration)
+			assert.Equal(t, []KillEvent{kill}, m.KillFeed)
+		})
+	}
+}
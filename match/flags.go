@@ -0,0 +1,211 @@
+package match
+
+import (
+	"log-parser/event"
+	"time"
+)
+
+// flagReturnTime is how long a dropped flag lies on the ground before the
+// server returns it to its base without logging it.
+const flagReturnTime = event.GameTime(40 * time.Second)
+
+// pitDeath is how a player falling off the map dies. A flag carried into a pit
+// is returned to its base straight away.
+const pitDeath = "MOD_TRIGGER_HURT"
+
+type (
+	// FlagStats counts what a player did with the flags in a CTF match. The
+	// log only says who touched a flag, so returns and captures are inferred
+	// from the team of the player and where both flags were at the time.
+	FlagStats struct {
+		Pickups      int `json:"pickups"`
+		Returns      int `json:"returns"`
+		Captures     int `json:"captures"`
+		CarrierKills int `json:"carrier_kills"`
+	}
+
+	CTFTeam struct {
+		Team     Team `json:"team"`
+		Captures int  `json:"captures"`
+		Score    int  `json:"score"`
+	}
+
+	// CTFSummary sets the captures inferred for each team against the team
+	// score reported by the server, along with the flag stats per player.
+	CTFSummary struct {
+		Teams   []CTFTeam             `json:"teams"`
+		Players map[string]*FlagStats `json:"players"`
+	}
+
+	flagState struct {
+		carried   bool
+		carrier   int
+		dropped   bool
+		droppedAt event.GameTime
+	}
+)
+
+func (m *Match) FlagStats(player string) *FlagStats {
+	if m.Flags == nil {
+		m.Flags = make(map[string]*FlagStats)
+	}
+
+	stats, ok := m.Flags[player]
+	if !ok {
+		stats = &FlagStats{}
+		m.Flags[player] = stats
+	}
+
+	return stats
+}
+
+// TouchFlag records the player on the client slot touching the flag of the
+// given team. Touching the enemy flag picks it up, while touching the own flag
+// either returns it or, when carrying the enemy flag, captures it.
+func (m *Match) TouchFlag(id int, flagTeam Team, at event.GameTime) {
+	m.observe(at)
+
+	player, ok := m.slots[id]
+	if !ok {
+		return
+	}
+
+	stats := m.FlagStats(player.Name)
+	flag := m.flag(flagTeam)
+
+	if player.Team != flagTeam {
+		stats.Pickups++
+		flag.carry(id)
+
+		return
+	}
+
+	enemyFlag := m.flag(opponent(flagTeam))
+	if enemyFlag.carried && enemyFlag.carrier == id && !flag.isAway(at) {
+		if m.captures == nil {
+			m.captures = make(map[Team]int)
+		}
+
+		stats.Captures++
+		m.captures[player.Team]++
+		enemyFlag.reset()
+
+		return
+	}
+
+	stats.Returns++
+	flag.reset()
+}
+
+// KillFlagCarrier drops the flag carried by the victim, if any, and credits
+// the killer with a flag carrier kill.
+func (m *Match) KillFlagCarrier(killerID, victimID int, means string, at event.GameTime) {
+	carrier := false
+	for _, flag := range m.flags {
+		if !flag.carried || flag.carrier != victimID {
+			continue
+		}
+
+		carrier = true
+		if means == pitDeath {
+			flag.reset()
+		} else {
+			flag.drop(at)
+		}
+	}
+
+	if !carrier || killerID == victimID {
+		return
+	}
+
+	if killer, ok := m.slots[killerID]; ok {
+		m.FlagStats(killer.Name).CarrierKills++
+	}
+}
+
+func (m *Match) CTF() CTFSummary {
+	summary := CTFSummary{
+		Teams: []CTFTeam{
+			{Team: TeamRed, Captures: m.captures[TeamRed]},
+			{Team: TeamBlue, Captures: m.captures[TeamBlue]},
+		},
+		Players: make(map[string]*FlagStats),
+	}
+
+	if m.TeamScore != nil {
+		summary.Teams[0].Score = m.TeamScore.Red
+		summary.Teams[1].Score = m.TeamScore.Blue
+	}
+
+	// players who never touched a flag are reported without adding them to
+	// the flags of the match.
+	for _, player := range m.Players {
+		stats, ok := m.Flags[player]
+		if !ok {
+			stats = &FlagStats{}
+		}
+
+		summary.Players[player] = stats
+	}
+
+	return summary
+}
+
+func (m *Match) flag(team Team) *flagState {
+	if m.flags == nil {
+		m.flags = make(map[Team]*flagState)
+	}
+
+	flag, ok := m.flags[team]
+	if !ok {
+		flag = &flagState{}
+		m.flags[team] = flag
+	}
+
+	return flag
+}
+
+func (m *Match) dropFlags(id int, at event.GameTime) bool {
+	dropped := false
+	for _, flag := range m.flags {
+		if flag.carried && flag.carrier == id {
+			flag.drop(at)
+			dropped = true
+		}
+	}
+
+	return dropped
+}
+
+func (s *FlagStats) merge(other *FlagStats) {
+	s.Pickups += other.Pickups
+	s.Returns += other.Returns
+	s.Captures += other.Captures
+	s.CarrierKills += other.CarrierKills
+}
+
+func (f *flagState) carry(id int) {
+	*f = flagState{carried: true, carrier: id}
+}
+
+func (f *flagState) drop(at event.GameTime) {
+	*f = flagState{dropped: true, droppedAt: at}
+}
+
+func (f *flagState) reset() {
+	*f = flagState{}
+}
+
+// isAway tells whether the flag is off its base, which is never the case once
+// a dropped flag has been lying around for longer than the return time.
+func (f *flagState) isAway(at event.GameTime) bool {
+	return f.carried || (f.dropped && at-f.droppedAt < flagReturnTime)
+}
+
+func opponent(team Team) Team {
+	if team == TeamRed {
+		return TeamBlue
+	}
+
+	return TeamRed
+}
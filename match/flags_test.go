@@ -0,0 +1,154 @@
+package match
+
+import (
+	"log-parser/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_TouchFlag(t *testing.T) {
+	type flagEvent struct {
+		touch    bool
+		id       int
+		flag     Team
+		killerID int
+		means    string
+		at       event.GameTime
+	}
+	tests := []struct {
+		name         string
+		events       []flagEvent
+		wantFlags    map[string]*FlagStats
+		wantCaptures []CTFTeam
+	}{
+		{
+			name: "should capture the enemy flag when touching the own flag at base",
+			events: []flagEvent{
+				{touch: true, id: 2, flag: TeamBlue, at: event.NewGameTime(1, 0)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 20)},
+			},
+			wantFlags: map[string]*FlagStats{
+				"Isgalamido": {Pickups: 1, Captures: 1},
+				"Zeh":        {},
+			},
+			wantCaptures: []CTFTeam{
+				{Team: TeamRed, Captures: 1},
+				{Team: TeamBlue},
+			},
+		},
+		{
+			name: "should return the own flag dropped by a killed carrier",
+			events: []flagEvent{
+				{touch: true, id: 3, flag: TeamRed, at: event.NewGameTime(1, 0)},
+				{killerID: 2, id: 3, means: "MOD_RAILGUN", at: event.NewGameTime(1, 5)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 10)},
+			},
+			wantFlags: map[string]*FlagStats{
+				"Isgalamido": {Returns: 1, CarrierKills: 1},
+				"Zeh":        {Pickups: 1},
+			},
+			wantCaptures: []CTFTeam{
+				{Team: TeamRed},
+				{Team: TeamBlue},
+			},
+		},
+		{
+			name: "should return the own flag before capturing while carrying the enemy flag",
+			events: []flagEvent{
+				{touch: true, id: 2, flag: TeamBlue, at: event.NewGameTime(1, 0)},
+				{touch: true, id: 3, flag: TeamRed, at: event.NewGameTime(1, 2)},
+				{killerID: 2, id: 3, means: "MOD_RAILGUN", at: event.NewGameTime(1, 5)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 10)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 20)},
+			},
+			wantFlags: map[string]*FlagStats{
+				"Isgalamido": {Pickups: 1, Returns: 1, Captures: 1, CarrierKills: 1},
+				"Zeh":        {Pickups: 1},
+			},
+			wantCaptures: []CTFTeam{
+				{Team: TeamRed, Captures: 1},
+				{Team: TeamBlue},
+			},
+		},
+		{
+			name: "should capture once a dropped own flag went back to base on its own",
+			events: []flagEvent{
+				{touch: true, id: 3, flag: TeamRed, at: event.NewGameTime(1, 0)},
+				{killerID: 1022, id: 3, means: "MOD_FALLING", at: event.NewGameTime(1, 5)},
+				{touch: true, id: 2, flag: TeamBlue, at: event.NewGameTime(1, 20)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 50)},
+			},
+			wantFlags: map[string]*FlagStats{
+				"Isgalamido": {Pickups: 1, Captures: 1},
+				"Zeh":        {Pickups: 1},
+			},
+			wantCaptures: []CTFTeam{
+				{Team: TeamRed, Captures: 1},
+				{Team: TeamBlue},
+			},
+		},
+		{
+			name: "should capture right after the enemy carried the own flag into a pit",
+			events: []flagEvent{
+				{touch: true, id: 2, flag: TeamBlue, at: event.NewGameTime(1, 0)},
+				{touch: true, id: 3, flag: TeamRed, at: event.NewGameTime(1, 2)},
+				{killerID: 1022, id: 3, means: "MOD_TRIGGER_HURT", at: event.NewGameTime(1, 5)},
+				{touch: true, id: 2, flag: TeamRed, at: event.NewGameTime(1, 10)},
+			},
+			wantFlags: map[string]*FlagStats{
+				"Isgalamido": {Pickups: 1, Captures: 1},
+				"Zeh":        {Pickups: 1},
+			},
+			wantCaptures: []CTFTeam{
+				{Team: TeamRed, Captures: 1},
+				{Team: TeamBlue},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+			m.SetPlayerName(2, "Isgalamido")
+			m.SetPlayerTeam(2, TeamRed, 0)
+			m.SetPlayerName(3, "Zeh")
+			m.SetPlayerTeam(3, TeamBlue, 0)
+
+			for _, event := range tt.events {
+				if event.touch {
+					m.TouchFlag(event.id, event.flag, event.at)
+				} else {
+					m.KillFlagCarrier(event.killerID, event.id, event.means, event.at)
+				}
+			}
+
+			summary := m.CTF()
+			assert.Equal(t, tt.wantFlags, summary.Players)
+			assert.Equal(t, tt.wantCaptures, summary.Teams)
+		})
+	}
+}
+
+func TestMatch_TouchFlag_Rename(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerTeam(2, TeamRed, 0)
+	m.TouchFlag(2, TeamBlue, event.NewGameTime(1, 0))
+	m.SetPlayerName(2, "Mocinha")
+
+	assert.Equal(t, map[string]*FlagStats{"Mocinha": {Pickups: 1}}, m.Flags)
+}
+
+func TestMatch_CTF(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerTeam(2, TeamRed, 0)
+	m.SetPlayerName(3, "Zeh")
+	m.SetPlayerTeam(3, TeamBlue, 0)
+	m.TouchFlag(2, TeamBlue, event.NewGameTime(1, 0))
+
+	summary := m.CTF()
+
+	assert.Equal(t, map[string]*FlagStats{"Isgalamido": {Pickups: 1}, "Zeh": {}}, summary.Players)
+	assert.Equal(t, map[string]*FlagStats{"Isgalamido": {Pickups: 1}}, m.Flags)
+}
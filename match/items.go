@@ -0,0 +1,126 @@
+package match
+
+import (
+	"log-parser/event"
+	"slices"
+	"strings"
+)
+
+const (
+	ItemWeapon   = "weapon"
+	ItemAmmo     = "ammo"
+	ItemArmor    = "armor"
+	ItemHealth   = "health"
+	ItemPowerup  = "powerup"
+	ItemHoldable = "holdable"
+	ItemFlag     = "flag"
+	ItemOther    = "other"
+)
+
+type (
+	// ItemControl tells how many times an item was picked up in the match and
+	// who took it the most. Ties go to whoever joined the match first.
+	ItemControl struct {
+		Item          string `json:"item"`
+		Category      string `json:"category"`
+		Pickups       int    `json:"pickups"`
+		Leader        string `json:"leader"`
+		LeaderPickups int    `json:"leader_pickups"`
+	}
+)
+
+// ItemCategory groups an item by its class name, e.g. weapon_railgun is a
+// weapon and item_quad a powerup.
+func ItemCategory(item string) string {
+	switch {
+	case strings.HasPrefix(item, "weapon_"):
+		return ItemWeapon
+	case strings.HasPrefix(item, "ammo_"):
+		return ItemAmmo
+	case strings.HasPrefix(item, "item_armor_"):
+		return ItemArmor
+	case strings.HasPrefix(item, "item_health"):
+		return ItemHealth
+	case strings.HasPrefix(item, "holdable_"):
+		return ItemHoldable
+	case strings.HasPrefix(item, "team_CTF_"):
+		return ItemFlag
+	case strings.HasPrefix(item, "item_"):
+		return ItemPowerup
+	default:
+		return ItemOther
+	}
+}
+
+// PickUpItem records the player on the client slot picking up the item.
+func (m *Match) PickUpItem(id int, item string, at event.GameTime) {
+	m.observe(at)
+
+	player, ok := m.slots[id]
+	if !ok {
+		return
+	}
+
+	if m.Items == nil {
+		m.Items = make(map[string]map[string]int)
+	}
+
+	items, ok := m.Items[player.Name]
+	if !ok {
+		items = make(map[string]int)
+		m.Items[player.Name] = items
+	}
+
+	items[item]++
+}
+
+func (m *Match) ItemControl() []ItemControl {
+	byItem := make(map[string]*ItemControl)
+	for _, player := range m.Players {
+		for item, pickups := range m.Items[player] {
+			control, ok := byItem[item]
+			if !ok {
+				control = &ItemControl{Item: item, Category: ItemCategory(item)}
+				byItem[item] = control
+			}
+
+			control.Pickups += pickups
+			if pickups > control.LeaderPickups {
+				control.Leader = player
+				control.LeaderPickups = pickups
+			}
+		}
+	}
+
+	controls := make([]ItemControl, 0, len(byItem))
+	for _, control := range byItem {
+		controls = append(controls, *control)
+	}
+
+	slices.SortFunc(controls, func(a, b ItemControl) int {
+		if c := strings.Compare(a.Category, b.Category); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Item, b.Item)
+	})
+
+	return controls
+}
+
+func (m *Match) renameItems(oldName, newName string) {
+	items, ok := m.Items[oldName]
+	if !ok {
+		return
+	}
+
+	delete(m.Items, oldName)
+
+	if m.Items[newName] == nil {
+		m.Items[newName] = make(map[string]int)
+	}
+
+	for item, pickups := range items {
+		m.Items[newName][item] += pickups
+	}
+}
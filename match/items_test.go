@@ -0,0 +1,73 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemCategory(t *testing.T) {
+	tests := []struct {
+		item string
+		want string
+	}{
+		{item: "weapon_rocketlauncher", want: ItemWeapon},
+		{item: "ammo_rockets", want: ItemAmmo},
+		{item: "item_armor_body", want: ItemArmor},
+		{item: "item_armor_shard", want: ItemArmor},
+		{item: "item_health", want: ItemHealth},
+		{item: "item_health_mega", want: ItemHealth},
+		{item: "item_quad", want: ItemPowerup},
+		{item: "holdable_medkit", want: ItemHoldable},
+		{item: "team_CTF_redflag", want: ItemFlag},
+		{item: "unknown", want: ItemOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.item, func(t *testing.T) {
+			assert.Equal(t, tt.want, ItemCategory(tt.item))
+		})
+	}
+}
+
+func TestMatch_ItemControl(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerName(3, "Zeh")
+
+	pickups := []struct {
+		id   int
+		item string
+	}{
+		{id: 2, item: "item_health_mega"},
+		{id: 3, item: "item_health_mega"},
+		{id: 3, item: "item_health_mega"},
+		{id: 3, item: "item_armor_body"},
+		{id: 2, item: "item_armor_body"},
+		{id: 2, item: "weapon_railgun"},
+		{id: 9, item: "weapon_railgun"},
+	}
+	for _, pickup := range pickups {
+		m.PickUpItem(pickup.id, pickup.item, 0)
+	}
+
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"item_health_mega": 1, "item_armor_body": 1, "weapon_railgun": 1},
+		"Zeh":        {"item_health_mega": 2, "item_armor_body": 1},
+	}, m.Items)
+
+	assert.Equal(t, []ItemControl{
+		{Item: "item_armor_body", Category: ItemArmor, Pickups: 2, Leader: "Isgalamido", LeaderPickups: 1},
+		{Item: "item_health_mega", Category: ItemHealth, Pickups: 3, Leader: "Zeh", LeaderPickups: 2},
+		{Item: "weapon_railgun", Category: ItemWeapon, Pickups: 1, Leader: "Isgalamido", LeaderPickups: 1},
+	}, m.ItemControl())
+}
+
+func TestMatch_PickUpItem_Rename(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.PickUpItem(2, "item_quad", 0)
+	m.SetPlayerName(2, "Mocinha")
+	m.PickUpItem(2, "item_quad", 0)
+
+	assert.Equal(t, map[string]map[string]int{"Mocinha": {"item_quad": 2}}, m.Items)
+}
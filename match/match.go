@@ -1,22 +1,72 @@
 package match
 
+import (
+	"log-parser/event"
+	"time"
+)
+
 const (
 	world = "<world>"
 )
 
 type (
+	// KillEvent is a kill as it happened, at the game time of its log line.
+	// The names are the ones the log printed, while the kill is counted for
+	// the players on the client slots, whose keys it keeps. A key is 0 for
+	// the world or a slot no player was on.
+	KillEvent struct {
+		At        event.GameTime `json:"at"`
+		KillerKey int            `json:"killer_key"`
+		VictimKey int            `json:"victim_key"`
+		KillerID  int            `json:"killer_id"`
+		VictimID  int            `json:"victim_id"`
+		Killer    string         `json:"killer"`
+		Victim    string         `json:"victim"`
+		Means     string         `json:"means"`
+		Time      *time.Time     `json:"time,omitempty"`
+	}
+
 	Match struct {
-		TotalKills    int             `json:"total_kills"`
-		Players       []string        `json:"players"`
-		Kills         map[string]int  `json:"kills"`
-		KillsByMeans  map[string]int  `json:"-"`
-		PlayersInGame map[string]bool `json:"-"`
-		Done          bool            `json:"-"`
-		InProgress    bool            `json:"-"`
+		Index         int                       `json:"-"`
+		StartLine     int                       `json:"-"`
+		EndLine       int                       `json:"-"`
+		Settings      MatchSettings             `json:"settings"`
+		StartedAt     event.GameTime            `json:"started_at"`
+		EndedAt       event.GameTime            `json:"ended_at"`
+		Duration      event.GameTime            `json:"duration"`
+		StartTime     *time.Time                `json:"start_time,omitempty"`
+		EndTime       *time.Time                `json:"end_time,omitempty"`
+		TotalKills    int                       `json:"total_kills"`
+		Players       []string                  `json:"players"`
+		Kills         map[string]int            `json:"kills"`
+		KillFeed      []KillEvent               `json:"kill_feed"`
+		Stats         map[string]*PlayerStats   `json:"stats"`
+		KillMatrix    map[string]map[string]int `json:"kill_matrix"`
+		KillsByMeans  map[string]int            `json:"-"`
+		PlayersInGame map[string]bool           `json:"-"`
+		Roster        []*Player                 `json:"roster"`
+		ExitReason    string                    `json:"exit_reason"`
+		Scoreboard    []ScoreEntry              `json:"scoreboard"`
+		TeamScore     *TeamScore                `json:"team_score,omitempty"`
+		Flags         map[string]*FlagStats     `json:"flags,omitempty"`
+		Items         map[string]map[string]int `json:"items"`
+		Chat          []ChatMessage             `json:"chat"`
+		Done          bool                      `json:"-"`
+		InProgress    bool                      `json:"-"`
+
+		slots      map[int]*Player
+		playerKeys int
+		sessions   map[int]*Session
+		clock      event.GameTime
+		wallClock  *time.Time
+		flags      map[Team]*flagState
+		captures   map[Team]int
 	}
 
 	Summary struct {
-		KillsByMeans map[string]int `json:"kills_by_means"`
+		KillsByMeans           map[string]int            `json:"kills_by_means"`
+		KillsByMeansPerPlayer  map[string]map[string]int `json:"kills_by_means_per_player"`
+		DeathsByMeansPerPlayer map[string]map[string]int `json:"deaths_by_means_per_player"`
 	}
 )
 
@@ -25,10 +75,22 @@ func NewMatch() *Match {
 		TotalKills:    0,
 		Players:       make([]string, 0),
 		Kills:         make(map[string]int),
+		KillFeed:      make([]KillEvent, 0),
+		Stats:         make(map[string]*PlayerStats),
+		KillMatrix:    make(map[string]map[string]int),
 		KillsByMeans:  make(map[string]int),
 		PlayersInGame: make(map[string]bool),
+		Roster:        make([]*Player, 0),
+		Scoreboard:    make([]ScoreEntry, 0),
+		Flags:         make(map[string]*FlagStats),
+		Items:         make(map[string]map[string]int),
+		Chat:          make([]ChatMessage, 0),
 		Done:          false,
 		InProgress:    false,
+		slots:         make(map[int]*Player),
+		sessions:      make(map[int]*Session),
+		flags:         make(map[Team]*flagState),
+		captures:      make(map[Team]int),
 	}
 }
 
@@ -37,11 +99,44 @@ func (m *Match) AddKillStats(player string) {
 	if !ok {
 		m.Kills[player] = 0
 	}
+
+	m.PlayerStats(player)
+}
+
+// AddKill records the kill in the kill feed and counts it like
+// AddKillAndMeans does for the players on the killer and victim slots, so two
+// players printed with the same name are told apart.
+func (m *Match) AddKill(kill KillEvent) {
+	m.observe(kill.At)
+
+	if killer, ok := m.slots[kill.KillerID]; ok {
+		kill.KillerKey = killer.Key
+	}
+	if victim, ok := m.slots[kill.VictimID]; ok {
+		kill.VictimKey = victim.Key
+	}
+
+	m.KillFeed = append(m.KillFeed, kill)
+	m.AddKillAndMeans(m.playerName(kill.KillerID, kill.Killer), m.playerName(kill.VictimID, kill.Victim), kill.Means)
+}
+
+// rekey moves the kill from the player with key from to the one with key to,
+// as when a player turns out to be one seen before.
+func (k *KillEvent) rekey(from, to int) {
+	if k.KillerKey == from {
+		k.KillerKey = to
+	}
+
+	if k.VictimKey == from {
+		k.VictimKey = to
+	}
 }
 
 func (m *Match) AddKillAndMeans(killer, killed, reason string) {
 	m.KillsByMeans[reason]++
 	m.TotalKills++
+	m.addKillToStats(killer, killed, reason)
+	m.addKillToMatrix(killer, killed)
 
 	if killer == killed {
 		return
@@ -53,3 +148,19 @@ func (m *Match) AddKillAndMeans(killer, killed, reason string) {
 		m.Kills[killer]++
 	}
 }
+
+func (m *Match) Summary() Summary {
+	summary := Summary{
+		KillsByMeans:           m.KillsByMeans,
+		KillsByMeansPerPlayer:  make(map[string]map[string]int),
+		DeathsByMeansPerPlayer: make(map[string]map[string]int),
+	}
+
+	for _, player := range m.Players {
+		stats := m.PlayerStats(player)
+		summary.KillsByMeansPerPlayer[player] = stats.KillsByMeans
+		summary.DeathsByMeansPerPlayer[player] = stats.DeathsByMeans
+	}
+
+	return summary
+}
@@ -0,0 +1,122 @@
+package match
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type (
+	// Rivalry tells who killed the player the most, their nemesis, and who the
+	// player killed the most. Ties go to whoever joined the match first.
+	Rivalry struct {
+		Player         string `json:"player"`
+		Nemesis        string `json:"nemesis,omitempty"`
+		NemesisKills   int    `json:"nemesis_kills"`
+		Dominated      string `json:"dominated,omitempty"`
+		DominatedKills int    `json:"dominated_kills"`
+	}
+)
+
+func (m *Match) addKillToMatrix(killer, killed string) {
+	if killer == world || killer == killed {
+		return
+	}
+
+	if m.KillMatrix == nil {
+		m.KillMatrix = make(map[string]map[string]int)
+	}
+
+	victims, ok := m.KillMatrix[killer]
+	if !ok {
+		victims = make(map[string]int)
+		m.KillMatrix[killer] = victims
+	}
+
+	victims[killed]++
+}
+
+func (m *Match) renameInMatrix(oldName, newName string) {
+	if victims, ok := m.KillMatrix[oldName]; ok {
+		delete(m.KillMatrix, oldName)
+
+		if m.KillMatrix[newName] == nil {
+			m.KillMatrix[newName] = make(map[string]int)
+		}
+
+		for victim, kills := range victims {
+			m.KillMatrix[newName][victim] += kills
+		}
+	}
+
+	for _, victims := range m.KillMatrix {
+		if kills, ok := victims[oldName]; ok {
+			delete(victims, oldName)
+			victims[newName] += kills
+		}
+	}
+}
+
+func (m *Match) Rivalries() []Rivalry {
+	rivalries := make([]Rivalry, 0, len(m.Players))
+
+	for _, player := range m.Players {
+		rivalry := Rivalry{Player: player}
+
+		for _, other := range m.Players {
+			if kills := m.KillMatrix[other][player]; kills > rivalry.NemesisKills {
+				rivalry.Nemesis = other
+				rivalry.NemesisKills = kills
+			}
+
+			if kills := m.KillMatrix[player][other]; kills > rivalry.DominatedKills {
+				rivalry.Dominated = other
+				rivalry.DominatedKills = kills
+			}
+		}
+
+		rivalries = append(rivalries, rivalry)
+	}
+
+	return rivalries
+}
+
+// WriteKillMatrix writes the kill matrix as a table with a row per killer and
+// a column per victim, followed by the rivalries of every player.
+func (m *Match) WriteKillMatrix(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "killer \\ victim\t%s\n", strings.Join(m.Players, "\t"))
+	for _, killer := range m.Players {
+		fmt.Fprint(tw, killer)
+		for _, victim := range m.Players {
+			fmt.Fprintf(tw, "\t%d", m.KillMatrix[killer][victim])
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "player\tnemesis\tdominated")
+	for _, rivalry := range m.Rivalries() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n",
+			rivalry.Player,
+			formatRival(rivalry.Nemesis, rivalry.NemesisKills),
+			formatRival(rivalry.Dominated, rivalry.DominatedKills),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func formatRival(player string, kills int) string {
+	if player == "" {
+		return "-"
+	}
+
+	return fmt.Sprintf("%s (%d)", player, kills)
+}
@@ -0,0 +1,69 @@
+package match
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newRivalryMatch() *Match {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerName(3, "Dono da Bola")
+	m.SetPlayerName(4, "Zeh")
+
+	m.AddKillAndMeans("Isgalamido", "Dono da Bola", "MOD_ROCKET")
+	m.AddKillAndMeans("Isgalamido", "Dono da Bola", "MOD_ROCKET")
+	m.AddKillAndMeans("Isgalamido", "Zeh", "MOD_ROCKET")
+	m.AddKillAndMeans("Zeh", "Isgalamido", "MOD_RAILGUN")
+	m.AddKillAndMeans("Zeh", "Zeh", "MOD_ROCKET_SPLASH")
+	m.AddKillAndMeans("<world>", "Dono da Bola", "MOD_FALLING")
+
+	return m
+}
+
+func TestMatch_KillMatrix(t *testing.T) {
+	m := newRivalryMatch()
+
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"Dono da Bola": 2, "Zeh": 1},
+		"Zeh":        {"Isgalamido": 1},
+	}, m.KillMatrix)
+
+	m.SetPlayerName(3, "Mocinha")
+
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"Mocinha": 2, "Zeh": 1},
+		"Zeh":        {"Isgalamido": 1},
+	}, m.KillMatrix)
+}
+
+func TestMatch_Rivalries(t *testing.T) {
+	m := newRivalryMatch()
+
+	assert.Equal(t, []Rivalry{
+		{Player: "Isgalamido", Nemesis: "Zeh", NemesisKills: 1, Dominated: "Dono da Bola", DominatedKills: 2},
+		{Player: "Dono da Bola", Nemesis: "Isgalamido", NemesisKills: 2},
+		{Player: "Zeh", Nemesis: "Isgalamido", NemesisKills: 1, Dominated: "Isgalamido", DominatedKills: 1},
+	}, m.Rivalries())
+}
+
+func TestMatch_WriteKillMatrix(t *testing.T) {
+	m := newRivalryMatch()
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.WriteKillMatrix(&buf))
+
+	want := "" +
+		"killer \\ victim  Isgalamido  Dono da Bola  Zeh\n" +
+		"Isgalamido       0           2             1\n" +
+		"Dono da Bola     0           0             0\n" +
+		"Zeh              1           0             0\n" +
+		"\n" +
+		"player        nemesis         dominated\n" +
+		"Isgalamido    Zeh (1)         Dono da Bola (2)\n" +
+		"Dono da Bola  Isgalamido (2)  -\n" +
+		"Zeh           Isgalamido (1)  Isgalamido (1)\n"
+
+	assert.Equal(t, want, buf.String())
+}
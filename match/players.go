@@ -0,0 +1,241 @@
+package match
+
+import (
+	"fmt"
+	"log-parser/event"
+	"slices"
+)
+
+type (
+	// Player is the identity of whoever is playing on a client slot. Renames
+	// done while connected are kept as aliases of the same player, and the
+	// stats of the match are kept under Name, which is unique in the match: a
+	// player taking the name of another connected player is told apart with a
+	// number, as in "Zeh (2)". ID is the slot the player was last on and Slots
+	// every slot they were on, while Key numbers the players of the match in
+	// the order they joined and never changes.
+	Player struct {
+		Key            int            `json:"key"`
+		ID             int            `json:"id"`
+		Slots          []int          `json:"slots"`
+		Name           string         `json:"name"`
+		Aliases        []string       `json:"aliases"`
+		Sessions       []*Session     `json:"sessions"`
+		Reconnects     int            `json:"reconnects"`
+		TimePlayed     event.GameTime `json:"time_played"`
+		LeftEarly      bool           `json:"left_early"`
+		KillsPerMinute float64        `json:"kills_per_minute"`
+		Team           Team           `json:"team"`
+		TeamChanges    []TeamChange   `json:"team_changes"`
+	}
+)
+
+func (m *Match) AddPlayer(player string) {
+	if m.PlayersInGame == nil {
+		m.PlayersInGame = make(map[string]bool)
+	}
+
+	_, ok := m.PlayersInGame[player]
+	if !ok {
+		m.Players = append(m.Players, player)
+		m.PlayersInGame[player] = true
+		m.AddKillStats(player)
+	}
+}
+
+// SetPlayerName binds the name to the player on the client slot, renaming
+// that player when the slot was already bound to another name.
+func (m *Match) SetPlayerName(id int, name string) {
+	if m.slots == nil {
+		m.slots = make(map[int]*Player)
+	}
+
+	// a client connecting frees its slot, so the first name seen after that
+	// starts a new binding instead of renaming the previous player.
+	player, ok := m.slots[id]
+	if !ok {
+		player = m.unboundPlayer(name, id)
+		if player == nil {
+			m.playerKeys++
+			player = &Player{
+				Key:     m.playerKeys,
+				Name:    m.uniqueName(name, ""),
+				Aliases: []string{name},
+			}
+			m.Roster = append(m.Roster, player)
+		}
+
+		player.bind(id)
+		m.slots[id] = player
+		m.attachSession(id, player)
+		m.AddPlayer(player.Name)
+
+		return
+	}
+
+	if player.alias() == name {
+		return
+	}
+
+	m.renamePlayer(player, name)
+}
+
+func (m *Match) PlayerByID(id int) *Player {
+	return m.slots[id]
+}
+
+// playerName is the name the stats of the player on the client slot are kept
+// under, or logName, the name the log printed, when no player is on the slot.
+func (m *Match) playerName(id int, logName string) string {
+	if player, ok := m.slots[id]; ok {
+		return player.Name
+	}
+
+	return logName
+}
+
+// unboundPlayer finds the roster player named name that the client on slot
+// id can be, leaving out players still connected on another slot: two
+// clients sharing a name are two players.
+func (m *Match) unboundPlayer(name string, id int) *Player {
+	for _, player := range m.Roster {
+		if player.alias() == name && !m.connectedElsewhere(player, id) {
+			return player
+		}
+	}
+
+	return nil
+}
+
+func (m *Match) connectedElsewhere(player *Player, id int) bool {
+	for slot, bound := range m.slots {
+		if bound != player || slot == id {
+			continue
+		}
+
+		if _, connected := m.sessions[slot]; connected {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Match) renamePlayer(player *Player, name string) {
+	oldName := player.Name
+
+	// the new name may belong to a player seen earlier in the match who left,
+	// in which case both are the same person and their stats are merged.
+	existing := m.unboundPlayer(name, player.ID)
+	if existing != nil {
+		// the slot the existing player left is no longer theirs.
+		for slot, bound := range m.slots {
+			if bound == existing {
+				delete(m.slots, slot)
+			}
+		}
+
+		for _, slot := range player.Slots {
+			existing.bind(slot)
+		}
+		existing.bind(player.ID)
+		existing.Aliases = appendAlias(existing.Aliases, player.Aliases...)
+		existing.Aliases = appendAlias(existing.Aliases, name)
+		existing.Sessions = append(existing.Sessions, player.Sessions...)
+		existing.TeamChanges = append(existing.TeamChanges, player.TeamChanges...)
+		existing.Team = player.Team
+
+		m.Roster = slices.DeleteFunc(m.Roster, func(p *Player) bool {
+			return p == player
+		})
+		m.slots[player.ID] = existing
+
+		for i := range m.KillFeed {
+			m.KillFeed[i].rekey(player.Key, existing.Key)
+		}
+
+		m.renameStats(oldName, existing.Name)
+
+		return
+	}
+
+	player.Aliases = appendAlias(player.Aliases, name)
+	player.Name = m.uniqueName(name, oldName)
+
+	m.renameStats(oldName, player.Name)
+}
+
+// uniqueName is name, or name followed by the first free number when another
+// player of the match goes by it. ownName is the name the player goes by, which
+// does not count as taken.
+func (m *Match) uniqueName(name, ownName string) string {
+	unique := name
+	for n := 2; unique != ownName && m.PlayersInGame[unique]; n++ {
+		unique = fmt.Sprintf("%s (%d)", name, n)
+	}
+
+	return unique
+}
+
+func (m *Match) renameStats(oldName, newName string) {
+	if oldName == newName {
+		return
+	}
+
+	if slices.Contains(m.Players, newName) {
+		m.Players = slices.DeleteFunc(m.Players, func(p string) bool {
+			return p == oldName
+		})
+	} else {
+		for i, p := range m.Players {
+			if p == oldName {
+				m.Players[i] = newName
+			}
+		}
+	}
+
+	m.Kills[newName] += m.Kills[oldName]
+	delete(m.Kills, oldName)
+
+	m.renameInMatrix(oldName, newName)
+	m.renameItems(oldName, newName)
+
+	if stats, ok := m.Stats[oldName]; ok {
+		m.PlayerStats(newName).merge(stats)
+		delete(m.Stats, oldName)
+	}
+
+	if stats, ok := m.Flags[oldName]; ok {
+		m.FlagStats(newName).merge(stats)
+		delete(m.Flags, oldName)
+	}
+
+	delete(m.PlayersInGame, oldName)
+	m.PlayersInGame[newName] = true
+}
+
+// alias is the name the log last printed for the player.
+func (p *Player) alias() string {
+	if len(p.Aliases) == 0 {
+		return p.Name
+	}
+
+	return p.Aliases[len(p.Aliases)-1]
+}
+
+func (p *Player) bind(id int) {
+	p.ID = id
+	if len(p.Slots) == 0 || p.Slots[len(p.Slots)-1] != id {
+		p.Slots = append(p.Slots, id)
+	}
+}
+
+func appendAlias(aliases []string, names ...string) []string {
+	for _, name := range names {
+		if len(aliases) == 0 || aliases[len(aliases)-1] != name {
+			aliases = append(aliases, name)
+		}
+	}
+
+	return aliases
+}
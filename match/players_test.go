@@ -0,0 +1,187 @@
+package match
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatch_SetPlayerName(t *testing.T) {
+	type nameChange struct {
+		connect    bool
+		disconnect bool
+		id         int
+		name       string
+		kills      int
+	}
+	tests := []struct {
+		name        string
+		changes     []nameChange
+		wantPlayers []string
+		wantKills   map[string]int
+		wantRoster  []*Player
+	}{
+		{
+			name: "should keep the kills of a player that renames while connected",
+			changes: []nameChange{
+				{connect: true, id: 3},
+				{id: 3, name: "Dono da Bola", kills: 1},
+				{id: 3, name: "Mocinha"},
+			},
+			wantPlayers: []string{"Mocinha"},
+			wantKills: map[string]int{
+				"Mocinha": 1,
+			},
+			wantRoster: []*Player{
+				{ID: 3, Name: "Mocinha", Aliases: []string{"Dono da Bola", "Mocinha"}},
+			},
+		},
+		{
+			name: "should start a new player when another client connects on a freed slot",
+			changes: []nameChange{
+				{connect: true, id: 3},
+				{id: 3, name: "Isgalamido"},
+				{connect: true, id: 3},
+				{id: 3, name: "Dono da Bola"},
+			},
+			wantPlayers: []string{"Isgalamido", "Dono da Bola"},
+			wantKills: map[string]int{
+				"Isgalamido":   0,
+				"Dono da Bola": 0,
+			},
+			wantRoster: []*Player{
+				{ID: 3, Name: "Isgalamido", Aliases: []string{"Isgalamido"}},
+				{ID: 3, Name: "Dono da Bola", Aliases: []string{"Dono da Bola"}},
+			},
+		},
+		{
+			name: "should merge a player that renames to the name of a player seen before",
+			changes: []nameChange{
+				{connect: true, id: 2},
+				{id: 2, name: "Dono da Bola", kills: 1},
+				{disconnect: true, id: 2},
+				{connect: true, id: 3},
+				{id: 3, name: "Mocinha", kills: 2},
+				{id: 3, name: "Dono da Bola"},
+			},
+			wantPlayers: []string{"Dono da Bola"},
+			wantKills: map[string]int{
+				"Dono da Bola": 3,
+			},
+			wantRoster: []*Player{
+				{ID: 3, Slots: []int{2, 3}, Name: "Dono da Bola", Aliases: []string{"Dono da Bola", "Mocinha", "Dono da Bola"}},
+			},
+		},
+		{
+			name: "should keep two connected players apart when they end up with the same name",
+			changes: []nameChange{
+				{connect: true, id: 2},
+				{id: 2, name: "Dono da Bola", kills: 1},
+				{connect: true, id: 3},
+				{id: 3, name: "Mocinha", kills: 2},
+				{id: 3, name: "Dono da Bola"},
+				{connect: true, id: 4},
+				{id: 4, name: "Dono da Bola"},
+			},
+			wantPlayers: []string{"Dono da Bola", "Dono da Bola (2)", "Dono da Bola (3)"},
+			wantKills: map[string]int{
+				"Dono da Bola":     1,
+				"Dono da Bola (2)": 2,
+				"Dono da Bola (3)": 0,
+			},
+			wantRoster: []*Player{
+				{ID: 2, Slots: []int{2}, Name: "Dono da Bola", Aliases: []string{"Dono da Bola"}},
+				{ID: 3, Slots: []int{3}, Name: "Dono da Bola (2)", Aliases: []string{"Mocinha", "Dono da Bola"}},
+				{ID: 4, Slots: []int{4}, Name: "Dono da Bola (3)", Aliases: []string{"Dono da Bola"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+
+			for _, change := range tt.changes {
+				if change.connect {
+					m.ConnectClient(change.id, 0)
+					continue
+				}
+
+				if change.disconnect {
+					m.DisconnectClient(change.id, 0)
+					continue
+				}
+
+				m.SetPlayerName(change.id, change.name)
+
+				for i := 0; i < change.kills; i++ {
+					m.AddKill(KillEvent{KillerID: change.id, VictimID: 1, Killer: change.name, Victim: "Zeh", Means: "MOD_ROCKET"})
+				}
+			}
+
+			assert.Equal(t, tt.wantPlayers, m.Players)
+			assert.Equal(t, tt.wantKills, m.Kills)
+			assert.Equal(t, len(tt.wantRoster), len(m.Roster))
+			for i, wantPlayer := range tt.wantRoster {
+				assert.Equal(t, wantPlayer.ID, m.Roster[i].ID)
+				assert.Equal(t, wantPlayer.Name, m.Roster[i].Name)
+				assert.Equal(t, wantPlayer.Aliases, m.Roster[i].Aliases)
+				if wantPlayer.Slots != nil {
+					assert.Equal(t, wantPlayer.Slots, m.Roster[i].Slots)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch_AddKill_SameName(t *testing.T) {
+	m := NewMatch()
+	m.ConnectClient(2, 0)
+	m.SetPlayerName(2, "Zeh")
+	m.ConnectClient(3, 0)
+	m.SetPlayerName(3, "Mal")
+	m.SetPlayerName(3, "Zeh")
+
+	m.AddKill(KillEvent{KillerID: 2, VictimID: 3, Killer: "Zeh", Victim: "Zeh", Means: "MOD_RAILGUN"})
+	m.AddScore(ScoreEntry{Player: "Zeh", Score: 1, ClientID: 2})
+	m.AddScore(ScoreEntry{Player: "Zeh", Score: 0, ClientID: 3})
+
+	assert.Equal(t, []string{"Zeh", "Zeh (2)"}, m.Players)
+	assert.Equal(t, map[string]int{"Zeh": 1, "Zeh (2)": 0}, m.Kills)
+	assert.Equal(t, 1, m.Stats["Zeh"].Frags)
+	assert.Zero(t, m.Stats["Zeh (2)"].Suicides)
+	assert.Equal(t, 1, m.Stats["Zeh (2)"].Deaths)
+	assert.Empty(t, m.Discrepancies())
+}
+
+func TestMatch_SetPlayerName_Slots(t *testing.T) {
+	m := NewMatch()
+	m.ConnectClient(4, 0)
+	m.SetPlayerName(4, "Zeh")
+	m.DisconnectClient(4, 0)
+	m.ConnectClient(2, 0)
+	m.SetPlayerName(2, "Zeh")
+	m.DisconnectClient(2, 0)
+	m.ConnectClient(2, 0)
+	m.SetPlayerName(2, "Dono da Bola")
+
+	if assert.Len(t, m.Roster, 2) {
+		assert.Equal(t, []int{4, 2}, m.Roster[0].Slots)
+		assert.Equal(t, []int{2}, m.Roster[1].Slots)
+	}
+}
+
+func TestMatch_AddKill_Keys(t *testing.T) {
+	m := NewMatch()
+	m.ConnectClient(2, 0)
+	m.SetPlayerName(2, "Dono da Bola")
+	m.DisconnectClient(2, 0)
+	m.ConnectClient(3, 0)
+	m.SetPlayerName(3, "Mocinha")
+	m.AddKill(KillEvent{KillerID: 1022, VictimID: 3, Killer: "<world>", Victim: "Mocinha", Means: "MOD_FALLING"})
+	m.SetPlayerName(3, "Dono da Bola")
+
+	if assert.Len(t, m.Roster, 1) {
+		assert.Equal(t, 1, m.Roster[0].Key)
+	}
+	assert.Zero(t, m.KillFeed[0].KillerKey)
+	assert.Equal(t, 1, m.KillFeed[0].VictimKey)
+}
@@ -0,0 +1,44 @@
+package match
+
+type (
+	// Discrepancy is a player whose score computed from the kill lines differs
+	// from the score the server reported when the match exited.
+	Discrepancy struct {
+		Player   string `json:"player"`
+		Computed int    `json:"computed"`
+		Reported int    `json:"reported"`
+		Delta    int    `json:"delta"`
+	}
+)
+
+// Discrepancies compares the computed score of every player on the final
+// scoreboard against the reported one, in scoreboard order. Matches that did
+// not reach the scoreboard have nothing to reconcile, and neither do CTF
+// matches, whose reported scores add bonus points for captures, assists and
+// flag defences the kill lines do not tell.
+func (m *Match) Discrepancies() []Discrepancy {
+	discrepancies := make([]Discrepancy, 0)
+	if m.Settings.GameType == GameTypeCaptureTheFlag {
+		return discrepancies
+	}
+
+	for _, entry := range m.Scoreboard {
+		computed := 0
+		if stats, ok := m.Stats[entry.Player]; ok {
+			computed = stats.Score
+		}
+
+		if computed == entry.Score {
+			continue
+		}
+
+		discrepancies = append(discrepancies, Discrepancy{
+			Player:   entry.Player,
+			Computed: computed,
+			Reported: entry.Score,
+			Delta:    entry.Score - computed,
+		})
+	}
+
+	return discrepancies
+}
@@ -0,0 +1,80 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_Discrepancies(t *testing.T) {
+	tests := []struct {
+		name     string
+		gameType GameType
+		kills    [][3]string
+		score    []ScoreEntry
+		want     []Discrepancy
+	}{
+		{
+			name: "should not report players whose computed score matches the reported one",
+			kills: [][3]string{
+				{"Zeh", "Mal", "MOD_RAILGUN"},
+				{"Zeh", "Mal", "MOD_RAILGUN"},
+				{"Mal", "Mal", "MOD_ROCKET_SPLASH"},
+			},
+			score: []ScoreEntry{
+				{Player: "Zeh", Score: 2, ClientID: 2},
+				{Player: "Mal", Score: -1, ClientID: 3},
+			},
+			want: []Discrepancy{},
+		},
+		{
+			name: "should report the delta between the reported and the computed score",
+			kills: [][3]string{
+				{"Zeh", "Mal", "MOD_RAILGUN"},
+				{"<world>", "Mal", "MOD_TRIGGER_HURT"},
+			},
+			score: []ScoreEntry{
+				{Player: "Zeh", Score: 6, ClientID: 2},
+				{Player: "Mal", Score: -1, ClientID: 3},
+			},
+			want: []Discrepancy{
+				{Player: "Zeh", Computed: 1, Reported: 6, Delta: 5},
+			},
+		},
+		{
+			name: "should report a scoreboard player without computed stats",
+			score: []ScoreEntry{
+				{Player: "Isgalamido", Score: 3, ClientID: 2},
+			},
+			want: []Discrepancy{
+				{Player: "Isgalamido", Computed: 0, Reported: 3, Delta: 3},
+			},
+		},
+		{
+			name:     "should not reconcile the bonus points of a CTF match",
+			gameType: GameTypeCaptureTheFlag,
+			kills: [][3]string{
+				{"Zeh", "Mal", "MOD_RAILGUN"},
+			},
+			score: []ScoreEntry{
+				{Player: "Zeh", Score: 56, ClientID: 2},
+				{Player: "Mal", Score: 5, ClientID: 3},
+			},
+			want: []Discrepancy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+			m.Settings.GameType = tt.gameType
+			for _, kill := range tt.kills {
+				m.AddKillAndMeans(kill[0], kill[1], kill[2])
+			}
+			for _, entry := range tt.score {
+				m.AddScore(entry)
+			}
+
+			assert.Equal(t, tt.want, m.Discrepancies())
+		})
+	}
+}
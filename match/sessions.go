@@ -0,0 +1,100 @@
+package match
+
+import (
+	"log-parser/event"
+	"slices"
+)
+
+type (
+	// Session is a single connection of a player to the match. BeganAt is
+	// only set once the player entered the game and LeftAt once they
+	// disconnected.
+	Session struct {
+		ConnectedAt event.GameTime  `json:"connected_at"`
+		BeganAt     *event.GameTime `json:"began_at,omitempty"`
+		LeftAt      *event.GameTime `json:"left_at,omitempty"`
+	}
+)
+
+func (m *Match) ConnectClient(id int, at event.GameTime) {
+	if m.sessions == nil {
+		m.sessions = make(map[int]*Session)
+	}
+
+	m.observe(at)
+
+	delete(m.slots, id)
+	m.sessions[id] = &Session{
+		ConnectedAt: at,
+	}
+}
+
+func (m *Match) BeginClient(id int, at event.GameTime) {
+	m.observe(at)
+
+	session, ok := m.sessions[id]
+	if !ok || session.BeganAt != nil {
+		return
+	}
+
+	session.BeganAt = &at
+}
+
+func (m *Match) DisconnectClient(id int, at event.GameTime) {
+	m.observe(at)
+	m.dropFlags(id, at)
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+
+	session.LeftAt = &at
+	delete(m.sessions, id)
+}
+
+// CloseSessions ends the sessions still open when the match ended and works
+// out the time played by every player. When at is earlier than the last
+// game time seen, the clock was reset and the last time seen is used instead.
+func (m *Match) CloseSessions(at event.GameTime) {
+	end := max(at, m.clock)
+
+	for _, player := range m.Roster {
+		player.TimePlayed = 0
+		for _, session := range player.Sessions {
+			if session.BeganAt == nil {
+				continue
+			}
+
+			leftAt := end
+			if session.LeftAt != nil {
+				leftAt = *session.LeftAt
+			}
+
+			if leftAt > *session.BeganAt {
+				player.TimePlayed += leftAt - *session.BeganAt
+			}
+		}
+
+		player.Reconnects = max(len(player.Sessions)-1, 0)
+		player.LeftEarly = len(player.Sessions) > 0 && player.Sessions[len(player.Sessions)-1].LeftAt != nil
+
+		player.KillsPerMinute = 0
+		if player.TimePlayed > 0 {
+			player.KillsPerMinute = float64(m.PlayerStats(player.Name).Frags) / player.TimePlayed.Minutes()
+		}
+	}
+}
+
+func (m *Match) attachSession(id int, player *Player) {
+	session, ok := m.sessions[id]
+	if !ok || slices.Contains(player.Sessions, session) {
+		return
+	}
+
+	player.Sessions = append(player.Sessions, session)
+}
+
+func (m *Match) observe(at event.GameTime) {
+	m.clock = max(m.clock, at)
+}
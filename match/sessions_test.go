@@ -0,0 +1,60 @@
+package match
+
+import (
+	"github.com/stretchr/testify/assert"
+	"log-parser/event"
+	"testing"
+)
+
+func TestMatch_CloseSessions(t *testing.T) {
+	m := NewMatch()
+
+	m.ConnectClient(2, event.NewGameTime(20, 38))
+	m.SetPlayerName(2, "Isgalamido")
+	m.BeginClient(2, event.NewGameTime(20, 38))
+	m.DisconnectClient(2, event.NewGameTime(21, 8))
+
+	m.ConnectClient(2, event.NewGameTime(21, 15))
+	m.SetPlayerName(2, "Isgalamido")
+	m.BeginClient(2, event.NewGameTime(21, 18))
+
+	m.ConnectClient(3, event.NewGameTime(21, 51))
+	m.SetPlayerName(3, "Mocinha")
+	m.BeginClient(3, event.NewGameTime(21, 53))
+	m.AddKillAndMeans("Mocinha", "Isgalamido", "MOD_ROCKET")
+	m.AddKillAndMeans("Mocinha", "Isgalamido", "MOD_ROCKET")
+	m.DisconnectClient(3, event.NewGameTime(22, 53))
+
+	m.ConnectClient(4, event.NewGameTime(22, 0))
+	m.SetPlayerName(4, "Zeh")
+
+	m.CloseSessions(event.NewGameTime(23, 18))
+
+	isgalamido, mocinha, zeh := m.Roster[0], m.Roster[1], m.Roster[2]
+
+	assert.Len(t, isgalamido.Sessions, 2)
+	assert.Equal(t, 1, isgalamido.Reconnects)
+	assert.Equal(t, event.NewGameTime(2, 30), isgalamido.TimePlayed)
+	assert.False(t, isgalamido.LeftEarly)
+
+	assert.Equal(t, 0, mocinha.Reconnects)
+	assert.Equal(t, event.NewGameTime(1, 0), mocinha.TimePlayed)
+	assert.True(t, mocinha.LeftEarly)
+	assert.Equal(t, 2.0, mocinha.KillsPerMinute)
+
+	assert.Equal(t, event.GameTime(0), zeh.TimePlayed)
+	assert.Equal(t, 0.0, zeh.KillsPerMinute)
+}
+
+func TestMatch_CloseSessions_ClockReset(t *testing.T) {
+	m := NewMatch()
+
+	m.ConnectClient(2, event.NewGameTime(20, 37))
+	m.SetPlayerName(2, "Isgalamido")
+	m.BeginClient(2, event.NewGameTime(20, 37))
+	m.ConnectClient(3, event.NewGameTime(26, 9))
+
+	m.CloseSessions(0)
+
+	assert.Equal(t, event.NewGameTime(5, 32), m.Roster[0].TimePlayed)
+}
@@ -0,0 +1,100 @@
+package match
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	GameTypeFreeForAll GameType = iota
+	GameTypeTournament
+	GameTypeSinglePlayer
+	GameTypeTeamDeathmatch
+	GameTypeCaptureTheFlag
+)
+
+var gameTypeNames = map[GameType]string{
+	GameTypeFreeForAll:     "ffa",
+	GameTypeTournament:     "tournament",
+	GameTypeSinglePlayer:   "single_player",
+	GameTypeTeamDeathmatch: "tdm",
+	GameTypeCaptureTheFlag: "ctf",
+}
+
+type (
+	GameType int
+
+	MatchSettings struct {
+		MapName      string            `json:"map_name"`
+		GameType     GameType          `json:"game_type"`
+		FragLimit    int               `json:"frag_limit"`
+		TimeLimit    int               `json:"time_limit"`
+		CaptureLimit int               `json:"capture_limit"`
+		Hostname     string            `json:"hostname"`
+		Version      string            `json:"version"`
+		Protocol     int               `json:"protocol"`
+		Raw          map[string]string `json:"raw"`
+	}
+)
+
+// NewMatchSettings builds the typed settings from the InitGame key/value
+// pairs. Values that are not valid numbers are left as zero and can still be
+// read from Raw.
+func NewMatchSettings(raw map[string]string) MatchSettings {
+	return MatchSettings{
+		MapName:      raw["mapname"],
+		GameType:     GameType(atoi(raw["g_gametype"])),
+		FragLimit:    atoi(raw["fraglimit"]),
+		TimeLimit:    atoi(raw["timelimit"]),
+		CaptureLimit: atoi(raw["capturelimit"]),
+		Hostname:     raw["sv_hostname"],
+		Version:      raw["version"],
+		Protocol:     atoi(raw["protocol"]),
+		Raw:          raw,
+	}
+}
+
+func (t GameType) String() string {
+	name, ok := gameTypeNames[t]
+	if !ok {
+		return fmt.Sprintf("gametype_%d", int(t))
+	}
+
+	return name
+}
+
+func (t GameType) IsTeamGame() bool {
+	return t >= GameTypeTeamDeathmatch
+}
+
+func (t GameType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *GameType) UnmarshalText(text []byte) error {
+	for gameType, name := range gameTypeNames {
+		if name == string(text) {
+			*t = gameType
+			return nil
+		}
+	}
+
+	value, err := strconv.Atoi(strings.TrimPrefix(string(text), "gametype_"))
+	if err != nil {
+		return fmt.Errorf("unknown game type %q", text)
+	}
+
+	*t = GameType(value)
+
+	return nil
+}
+
+func atoi(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
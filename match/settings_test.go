@@ -0,0 +1,55 @@
+package match
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGameType_JSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		gameType GameType
+		wantJSON string
+	}{
+		{
+			name:     "should encode free for all by its name",
+			gameType: GameTypeFreeForAll,
+			wantJSON: `"ffa"`,
+		},
+		{
+			name:     "should encode capture the flag by its name",
+			gameType: GameTypeCaptureTheFlag,
+			wantJSON: `"ctf"`,
+		},
+		{
+			name:     "should encode an unknown game type by its number",
+			gameType: GameType(7),
+			wantJSON: `"gametype_7"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.gameType)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantJSON, string(got))
+
+			var decoded GameType
+			assert.NoError(t, json.Unmarshal(got, &decoded))
+			assert.Equal(t, tt.gameType, decoded)
+		})
+	}
+}
+
+func TestNewMatchSettings(t *testing.T) {
+	settings := NewMatchSettings(map[string]string{
+		"mapname":    "q3dm17",
+		"g_gametype": "= 0",
+		"fraglimit":  "20",
+	})
+
+	assert.Equal(t, "q3dm17", settings.MapName)
+	assert.Equal(t, GameTypeFreeForAll, settings.GameType)
+	assert.Equal(t, 20, settings.FragLimit)
+	assert.Equal(t, "= 0", settings.Raw["g_gametype"])
+}
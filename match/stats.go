@@ -0,0 +1,79 @@
+package match
+
+type (
+	// PlayerStats breaks down the kills of a player. Score follows the game
+	// rules: one point per frag, minus one per suicide or world death.
+	PlayerStats struct {
+		Frags         int            `json:"frags"`
+		Deaths        int            `json:"deaths"`
+		Suicides      int            `json:"suicides"`
+		WorldDeaths   int            `json:"world_deaths"`
+		Score         int            `json:"score"`
+		KDRatio       float64        `json:"kd_ratio"`
+		KillsByMeans  map[string]int `json:"kills_by_means"`
+		DeathsByMeans map[string]int `json:"deaths_by_means"`
+	}
+)
+
+func (m *Match) PlayerStats(player string) *PlayerStats {
+	if m.Stats == nil {
+		m.Stats = make(map[string]*PlayerStats)
+	}
+
+	stats, ok := m.Stats[player]
+	if !ok {
+		stats = &PlayerStats{
+			KillsByMeans:  make(map[string]int),
+			DeathsByMeans: make(map[string]int),
+		}
+		m.Stats[player] = stats
+	}
+
+	return stats
+}
+
+func (m *Match) addKillToStats(killer, killed, reason string) {
+	victim := m.PlayerStats(killed)
+	victim.Deaths++
+	victim.DeathsByMeans[reason]++
+
+	switch killer {
+	case killed:
+		victim.Suicides++
+	case world:
+		victim.WorldDeaths++
+	default:
+		stats := m.PlayerStats(killer)
+		stats.Frags++
+		stats.KillsByMeans[reason]++
+		stats.update()
+	}
+
+	victim.update()
+}
+
+func (s *PlayerStats) update() {
+	s.Score = s.Frags - s.Suicides - s.WorldDeaths
+
+	s.KDRatio = float64(s.Frags)
+	if s.Deaths > 0 {
+		s.KDRatio = float64(s.Frags) / float64(s.Deaths)
+	}
+}
+
+func (s *PlayerStats) merge(other *PlayerStats) {
+	s.Frags += other.Frags
+	s.Deaths += other.Deaths
+	s.Suicides += other.Suicides
+	s.WorldDeaths += other.WorldDeaths
+
+	for means, kills := range other.KillsByMeans {
+		s.KillsByMeans[means] += kills
+	}
+
+	for means, deaths := range other.DeathsByMeans {
+		s.DeathsByMeans[means] += deaths
+	}
+
+	s.update()
+}
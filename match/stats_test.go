@@ -0,0 +1,130 @@
+package match
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMatch_PlayerStats(t *testing.T) {
+	type kill struct {
+		killer string
+		killed string
+		reason string
+	}
+	tests := []struct {
+		name      string
+		kills     []kill
+		wantStats map[string]PlayerStats
+		wantKills map[string]int
+	}{
+		{
+			name: "should count frags and deaths of players killing each other",
+			kills: []kill{
+				{killer: "Isgalamido", killed: "Mocinha", reason: "MOD_ROCKET"},
+				{killer: "Isgalamido", killed: "Mocinha", reason: "MOD_RAILGUN"},
+				{killer: "Mocinha", killed: "Isgalamido", reason: "MOD_ROCKET"},
+			},
+			wantStats: map[string]PlayerStats{
+				"Isgalamido": {
+					Frags: 2, Deaths: 1, Score: 2, KDRatio: 2,
+					KillsByMeans:  map[string]int{"MOD_ROCKET": 1, "MOD_RAILGUN": 1},
+					DeathsByMeans: map[string]int{"MOD_ROCKET": 1},
+				},
+				"Mocinha": {
+					Frags: 1, Deaths: 2, Score: 1, KDRatio: 0.5,
+					KillsByMeans:  map[string]int{"MOD_ROCKET": 1},
+					DeathsByMeans: map[string]int{"MOD_ROCKET": 1, "MOD_RAILGUN": 1},
+				},
+			},
+			wantKills: map[string]int{
+				"Isgalamido": 2,
+				"Mocinha":    1,
+			},
+		},
+		{
+			name: "should count suicides and world deaths apart from frags",
+			kills: []kill{
+				{killer: "Zeh", killed: "Zeh", reason: "MOD_ROCKET_SPLASH"},
+				{killer: "<world>", killed: "Zeh", reason: "MOD_TRIGGER_HURT"},
+				{killer: "Zeh", killed: "Isgalamido", reason: "MOD_SHOTGUN"},
+			},
+			wantStats: map[string]PlayerStats{
+				"Zeh": {
+					Frags: 1, Deaths: 2, Suicides: 1, WorldDeaths: 1, Score: -1, KDRatio: 0.5,
+					KillsByMeans:  map[string]int{"MOD_SHOTGUN": 1},
+					DeathsByMeans: map[string]int{"MOD_ROCKET_SPLASH": 1, "MOD_TRIGGER_HURT": 1},
+				},
+				"Isgalamido": {
+					Deaths:        1,
+					KillsByMeans:  map[string]int{},
+					DeathsByMeans: map[string]int{"MOD_SHOTGUN": 1},
+				},
+			},
+			wantKills: map[string]int{
+				"Zeh":        0,
+				"Isgalamido": 0,
+			},
+		},
+		{
+			name: "should use the frags as the ratio of a player that never died",
+			kills: []kill{
+				{killer: "Mal", killed: "Zeh", reason: "MOD_MACHINEGUN"},
+				{killer: "Mal", killed: "Zeh", reason: "MOD_MACHINEGUN"},
+				{killer: "Mal", killed: "Zeh", reason: "MOD_MACHINEGUN"},
+			},
+			wantStats: map[string]PlayerStats{
+				"Mal": {
+					Frags: 3, Score: 3, KDRatio: 3,
+					KillsByMeans:  map[string]int{"MOD_MACHINEGUN": 3},
+					DeathsByMeans: map[string]int{},
+				},
+				"Zeh": {
+					Deaths:        3,
+					KillsByMeans:  map[string]int{},
+					DeathsByMeans: map[string]int{"MOD_MACHINEGUN": 3},
+				},
+			},
+			wantKills: map[string]int{
+				"Mal": 3,
+				"Zeh": 0,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+
+			for _, k := range tt.kills {
+				m.AddKillAndMeans(k.killer, k.killed, k.reason)
+			}
+
+			for player, wantStats := range tt.wantStats {
+				assert.Equal(t, wantStats, *m.Stats[player])
+			}
+
+			for player, wantKills := range tt.wantKills {
+				assert.Equal(t, wantKills, m.Kills[player])
+			}
+		})
+	}
+}
+
+func TestMatch_Summary(t *testing.T) {
+	m := NewMatch()
+	m.AddPlayer("Isgalamido")
+	m.AddPlayer("Zeh")
+	m.AddKillAndMeans("Isgalamido", "Zeh", "MOD_RAILGUN")
+	m.AddKillAndMeans("<world>", "Isgalamido", "MOD_FALLING")
+
+	summary := m.Summary()
+
+	assert.Equal(t, map[string]int{"MOD_RAILGUN": 1, "MOD_FALLING": 1}, summary.KillsByMeans)
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"MOD_RAILGUN": 1},
+		"Zeh":        {},
+	}, summary.KillsByMeansPerPlayer)
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"MOD_FALLING": 1},
+		"Zeh":        {"MOD_RAILGUN": 1},
+	}, summary.DeathsByMeansPerPlayer)
+}
@@ -0,0 +1,129 @@
+package match
+
+import (
+	"fmt"
+	"log-parser/event"
+	"strconv"
+	"strings"
+)
+
+const (
+	TeamFree Team = iota
+	TeamRed
+	TeamBlue
+	TeamSpectator
+)
+
+var teamNames = map[Team]string{
+	TeamFree:      "free",
+	TeamRed:       "red",
+	TeamBlue:      "blue",
+	TeamSpectator: "spectator",
+}
+
+type (
+	Team int
+
+	// TeamChange is a player joining a team, or the spectators, at the given
+	// game time.
+	TeamChange struct {
+		At   event.GameTime `json:"at"`
+		Team Team           `json:"team"`
+	}
+
+	TeamScore struct {
+		Red  int `json:"red"`
+		Blue int `json:"blue"`
+	}
+
+	// TeamSummary groups the players that ended the match on a team with the
+	// frags they made and the score the server reported for the team.
+	TeamSummary struct {
+		Team    Team     `json:"team"`
+		Players []string `json:"players"`
+		Frags   int      `json:"frags"`
+		Score   int      `json:"score"`
+	}
+)
+
+func (t Team) String() string {
+	name, ok := teamNames[t]
+	if !ok {
+		return fmt.Sprintf("team_%d", int(t))
+	}
+
+	return name
+}
+
+func (t Team) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Team) UnmarshalText(text []byte) error {
+	for team, name := range teamNames {
+		if name == string(text) {
+			*t = team
+			return nil
+		}
+	}
+
+	value, err := strconv.Atoi(strings.TrimPrefix(string(text), "team_"))
+	if err != nil {
+		return fmt.Errorf("unknown team %q", text)
+	}
+
+	*t = Team(value)
+
+	return nil
+}
+
+// SetPlayerTeam puts the player on the client slot on the team, recording a
+// team change whenever it differs from the team the player was on.
+func (m *Match) SetPlayerTeam(id int, team Team, at event.GameTime) {
+	player, ok := m.slots[id]
+	if !ok {
+		return
+	}
+
+	m.observe(at)
+
+	if len(player.TeamChanges) > 0 && player.Team == team {
+		return
+	}
+
+	player.Team = team
+	player.TeamChanges = append(player.TeamChanges, TeamChange{At: at, Team: team})
+}
+
+func (m *Match) SetTeamScore(red, blue int) {
+	m.TeamScore = &TeamScore{Red: red, Blue: blue}
+}
+
+// Teams summarises the red and blue teams by the team each player was on at
+// the end of the match.
+func (m *Match) Teams() []TeamSummary {
+	teams := []TeamSummary{
+		{Team: TeamRed, Players: make([]string, 0)},
+		{Team: TeamBlue, Players: make([]string, 0)},
+	}
+
+	if m.TeamScore != nil {
+		teams[0].Score = m.TeamScore.Red
+		teams[1].Score = m.TeamScore.Blue
+	}
+
+	for _, player := range m.Roster {
+		for i := range teams {
+			if teams[i].Team != player.Team {
+				continue
+			}
+
+			teams[i].Players = append(teams[i].Players, player.Name)
+			if stats, ok := m.Stats[player.Name]; ok {
+				teams[i].Frags += stats.Frags
+			}
+		}
+	}
+
+	return teams
+}
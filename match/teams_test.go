@@ -0,0 +1,107 @@
+package match
+
+import (
+	"encoding/json"
+	"log-parser/event"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_SetPlayerTeam(t *testing.T) {
+	type teamChange struct {
+		id   int
+		name string
+		team Team
+		at   event.GameTime
+	}
+	tests := []struct {
+		name            string
+		changes         []teamChange
+		wantTeam        Team
+		wantTeamChanges []TeamChange
+	}{
+		{
+			name: "should record a spectator joining a team",
+			changes: []teamChange{
+				{id: 2, name: "Isgalamido", team: TeamSpectator, at: event.NewGameTime(0, 26)},
+				{id: 2, name: "Isgalamido", team: TeamRed, at: event.NewGameTime(0, 30)},
+			},
+			wantTeam: TeamRed,
+			wantTeamChanges: []TeamChange{
+				{At: event.NewGameTime(0, 26), Team: TeamSpectator},
+				{At: event.NewGameTime(0, 30), Team: TeamRed},
+			},
+		},
+		{
+			name: "should not record a change when the player stays on the same team",
+			changes: []teamChange{
+				{id: 2, name: "Isgalamido", team: TeamBlue, at: event.NewGameTime(0, 26)},
+				{id: 2, name: "Isgalamido", team: TeamBlue, at: event.NewGameTime(0, 30)},
+			},
+			wantTeam: TeamBlue,
+			wantTeamChanges: []TeamChange{
+				{At: event.NewGameTime(0, 26), Team: TeamBlue},
+			},
+		},
+		{
+			name: "should keep the team history of a player that renames",
+			changes: []teamChange{
+				{id: 2, name: "Isgalamido", team: TeamRed, at: event.NewGameTime(0, 26)},
+				{id: 2, name: "Mocinha", team: TeamBlue, at: event.NewGameTime(1, 0)},
+			},
+			wantTeam: TeamBlue,
+			wantTeamChanges: []TeamChange{
+				{At: event.NewGameTime(0, 26), Team: TeamRed},
+				{At: event.NewGameTime(1, 0), Team: TeamBlue},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatch()
+			for _, change := range tt.changes {
+				m.SetPlayerName(change.id, change.name)
+				m.SetPlayerTeam(change.id, change.team, change.at)
+			}
+
+			player := m.PlayerByID(2)
+			assert.Equal(t, tt.wantTeam, player.Team)
+			assert.Equal(t, tt.wantTeamChanges, player.TeamChanges)
+		})
+	}
+}
+
+func TestMatch_Teams(t *testing.T) {
+	m := NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerTeam(2, TeamRed, 0)
+	m.SetPlayerName(3, "Zeh")
+	m.SetPlayerTeam(3, TeamBlue, 0)
+	m.SetPlayerName(4, "Mal")
+	m.SetPlayerTeam(4, TeamBlue, 0)
+	m.SetPlayerName(5, "Dono da Bola")
+	m.SetPlayerTeam(5, TeamSpectator, 0)
+	m.AddKillAndMeans("Isgalamido", "Zeh", "MOD_RAILGUN")
+	m.AddKillAndMeans("Zeh", "Isgalamido", "MOD_ROCKET")
+	m.AddKillAndMeans("Mal", "Isgalamido", "MOD_ROCKET")
+	m.SetTeamScore(1, 2)
+
+	want := []TeamSummary{
+		{Team: TeamRed, Players: []string{"Isgalamido"}, Frags: 1, Score: 1},
+		{Team: TeamBlue, Players: []string{"Zeh", "Mal"}, Frags: 2, Score: 2},
+	}
+
+	assert.Equal(t, want, m.Teams())
+}
+
+func TestTeam_JSON(t *testing.T) {
+	for _, team := range []Team{TeamFree, TeamRed, TeamBlue, TeamSpectator, Team(7)} {
+		data, err := json.Marshal(team)
+		assert.NoError(t, err)
+
+		var got Team
+		assert.NoError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, team, got)
+	}
+}
@@ -0,0 +1,190 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"log-parser/event"
+	"log-parser/match"
+	"os"
+	"time"
+)
+
+var ErrNoAnchor = errors.New("filtering matches by date needs a wall-clock anchor")
+
+type (
+	// anchor tells the wall-clock time of a point on the log timeline, which
+	// is the game clock of the log kept counting across the clock resets
+	// between matches.
+	anchor func(cfg *config, matches []*match.Match, offsets map[int]event.GameTime) (event.GameTime, time.Time, error)
+
+	referenceLine struct {
+		line     int
+		at       time.Time
+		found    bool
+		index    int
+		gameTime event.GameTime
+	}
+
+	dateRange struct {
+		from time.Time
+		to   time.Time
+	}
+)
+
+// WithStartTime anchors the start of the first match in the log to t.
+func WithStartTime(t time.Time) Option {
+	return func(c *config) {
+		c.anchor = func(_ *config, matches []*match.Match, offsets map[int]event.GameTime) (event.GameTime, time.Time, error) {
+			first := matches[0]
+
+			return offsets[first.Index] + first.StartedAt, t, nil
+		}
+	}
+}
+
+// WithFileModTime anchors the last game time in the log to the modification
+// time of the log file, as the server last wrote to it then. It only applies
+// when the source is a file, as with ParseLog.
+func WithFileModTime() Option {
+	return func(c *config) {
+		c.anchor = func(cfg *config, matches []*match.Match, offsets map[int]event.GameTime) (event.GameTime, time.Time, error) {
+			info, err := os.Stat(cfg.sourceName)
+			if err != nil {
+				return 0, time.Time{}, fmt.Errorf("reading the log file modification time: %w", err)
+			}
+
+			last := matches[len(matches)-1]
+
+			return offsets[last.Index] + matchEnd(last), info.ModTime(), nil
+		}
+	}
+}
+
+// WithReferenceLine anchors the game time of the given log line, counted from
+// 1, to t.
+func WithReferenceLine(line int, t time.Time) Option {
+	return func(c *config) {
+		c.reference = &referenceLine{line: line, at: t}
+		c.anchor = func(cfg *config, _ []*match.Match, offsets map[int]event.GameTime) (event.GameTime, time.Time, error) {
+			reference := cfg.reference
+			if !reference.found {
+				return 0, time.Time{}, fmt.Errorf("reference line %d has no game time", reference.line)
+			}
+
+			offset, ok := offsets[reference.index]
+			if !ok {
+				return 0, time.Time{}, fmt.Errorf("reference line %d is not in a parsed match", reference.line)
+			}
+
+			return offset + reference.gameTime, reference.at, nil
+		}
+	}
+}
+
+// WithDateRange keeps only the matches that started within [from, to). A zero
+// from or to leaves that end of the range open. It needs one of the anchors to
+// know when each match started.
+func WithDateRange(from, to time.Time) Option {
+	return func(c *config) {
+		c.dateRange = &dateRange{from: from, to: to}
+	}
+}
+
+// anchorMatches sets the wall clock of every match from the configured anchor
+// and filters them by the configured date range.
+func anchorMatches(cfg *config, matches []*match.Match) ([]*match.Match, error) {
+	if cfg.anchor == nil {
+		if cfg.dateRange != nil {
+			return nil, ErrNoAnchor
+		}
+
+		return matches, nil
+	}
+
+	if len(matches) == 0 {
+		return matches, nil
+	}
+
+	offsets := logTimeline(matches)
+
+	logTime, wallTime, err := cfg.anchor(cfg, matches, offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gameMatch := range matches {
+		zero := wallTime.Add((offsets[gameMatch.Index] - logTime).Duration())
+		gameMatch.SetWallClock(zero)
+	}
+
+	if cfg.dateRange == nil {
+		return matches, nil
+	}
+
+	filtered := make([]*match.Match, 0, len(matches))
+	for _, gameMatch := range matches {
+		if cfg.dateRange.contains(*gameMatch.StartTime) {
+			filtered = append(filtered, gameMatch)
+		}
+	}
+
+	return filtered, nil
+}
+
+// logTimeline works out where the game clock of each match, by index, sits on
+// the log timeline. A match starting earlier than the previous one ended means
+// the server restarted and the clock was reset, which is taken to happen right
+// after the previous match, as the log does not tell how long it took.
+func logTimeline(matches []*match.Match) map[int]event.GameTime {
+	offsets := make(map[int]event.GameTime, len(matches))
+
+	var offset, last event.GameTime
+	for _, gameMatch := range matches {
+		if gameMatch.StartedAt < last {
+			offset += last
+		}
+
+		offsets[gameMatch.Index] = offset
+		last = matchEnd(gameMatch)
+	}
+
+	return offsets
+}
+
+func matchEnd(gameMatch *match.Match) event.GameTime {
+	return max(gameMatch.StartedAt, gameMatch.EndedAt, gameMatch.Clock())
+}
+
+func (r *referenceLine) record(lineNumber int, logLine string, gathered gatheredMatch) {
+	if lineNumber != r.line {
+		return
+	}
+
+	at, ok := event.ParseGameTime(logLine)
+	if !ok {
+		return
+	}
+
+	// a line left out of the matches, such as the separator after a match,
+	// belongs to the timeline of the match before it.
+	index := gathered.index
+	if len(gathered.events) == 0 && index > 1 {
+		index--
+	}
+
+	r.found = true
+	r.index = index
+	r.gameTime = at
+}
+
+func (r *dateRange) contains(t time.Time) bool {
+	if !r.from.IsZero() && t.Before(r.from) {
+		return false
+	}
+
+	if !r.to.IsZero() && !t.Before(r.to) {
+		return false
+	}
+
+	return true
+}
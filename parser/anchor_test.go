@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"log-parser/event"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLog_Anchor(t *testing.T) {
+	anchorTime := time.Date(2024, time.March, 9, 21, 0, 0, 0, time.UTC)
+	at := func(minutes, seconds int) time.Time {
+		return anchorTime.Add(event.NewGameTime(minutes, seconds).Duration())
+	}
+
+	modTimeLog := filepath.Join(t.TempDir(), "games.log")
+	logContent, err := os.ReadFile("./testfiles/qgames_three_matches.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(modTimeLog, logContent, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(modTimeLog, at(27, 56), at(27, 56)); err != nil {
+		t.Fatal(err)
+	}
+
+	type wantTimes struct {
+		index     int
+		startTime time.Time
+		endTime   time.Time
+	}
+	tests := []struct {
+		name     string
+		filepath string
+		opts     []Option
+		want     []wantTimes
+	}{
+		{
+			name:     "should carry the log timeline over the clock reset when anchoring the start",
+			filepath: "./testfiles/qgames_three_matches.log",
+			opts:     []Option{WithStartTime(anchorTime)},
+			want: []wantTimes{
+				{index: 1, startTime: at(0, 0), endTime: at(20, 37)},
+				{index: 2, startTime: at(20, 37), endTime: at(26, 9)},
+				{index: 3, startTime: at(26, 9), endTime: at(27, 56)},
+			},
+		},
+		{
+			name:     "should anchor the game time of a reference line",
+			filepath: "./testfiles/qgames_three_matches.log",
+			opts:     []Option{WithReferenceLine(98, at(26, 9))},
+			want: []wantTimes{
+				{index: 1, startTime: at(0, 0), endTime: at(20, 37)},
+				{index: 2, startTime: at(20, 37), endTime: at(26, 9)},
+				{index: 3, startTime: at(26, 9), endTime: at(27, 56)},
+			},
+		},
+		{
+			name:     "should anchor the end of the log to the file modification time",
+			filepath: modTimeLog,
+			opts:     []Option{WithFileModTime()},
+			want: []wantTimes{
+				{index: 1, startTime: at(0, 0), endTime: at(20, 37)},
+				{index: 2, startTime: at(20, 37), endTime: at(26, 9)},
+				{index: 3, startTime: at(26, 9), endTime: at(27, 56)},
+			},
+		},
+		{
+			name:     "should keep only the matches that started within the date range",
+			filepath: "./testfiles/qgames_three_matches.log",
+			opts:     []Option{WithStartTime(anchorTime), WithDateRange(at(20, 0), at(26, 9))},
+			want: []wantTimes{
+				{index: 2, startTime: at(20, 37), endTime: at(26, 9)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLog(tt.filepath, tt.opts...)
+			assert.NoError(t, err)
+
+			if !assert.Len(t, got, len(tt.want)) {
+				return
+			}
+
+			for i, want := range tt.want {
+				assert.Equal(t, want.index, got[i].Index)
+				if assert.NotNil(t, got[i].StartTime) && assert.NotNil(t, got[i].EndTime) {
+					assert.True(t, want.startTime.Equal(*got[i].StartTime), "start time %v, want %v", got[i].StartTime, want.startTime)
+					assert.True(t, want.endTime.Equal(*got[i].EndTime), "end time %v, want %v", got[i].EndTime, want.endTime)
+				}
+			}
+		})
+	}
+
+	t.Run("should time the kills of an anchored match", func(t *testing.T) {
+		got, err := ParseLog("./testfiles/qgames_three_matches.log", WithStartTime(anchorTime))
+		assert.NoError(t, err)
+
+		kill := got[1].KillFeed[0]
+		if assert.NotNil(t, kill.Time) {
+			assert.True(t, at(20, 54).Equal(*kill.Time))
+		}
+	})
+
+	t.Run("should not filter by date without an anchor", func(t *testing.T) {
+		got, err := ParseLog("./testfiles/qgames_three_matches.log", WithDateRange(anchorTime, time.Time{}))
+		assert.ErrorIs(t, err, ErrNoAnchor)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should fail when the reference line has no game time", func(t *testing.T) {
+		got, err := ParseLog("./testfiles/qgames_three_matches.log", WithReferenceLine(1000, anchorTime))
+		assert.EqualError(t, err, "reference line 1000 has no game time")
+		assert.Nil(t, got)
+	})
+}
@@ -1,21 +1,15 @@
 package parser
 
 import (
+	"log-parser/event"
 	"log-parser/match"
-	"regexp"
-)
-
-var (
-	initGameRe             = regexp.MustCompile(`^\s*\d{1,3}:\d{2}\s+InitGame:.*$`)
-	clientUserInfoRe       = regexp.MustCompile(`ClientUserinfoChanged:\s+\d+\s+n\\([^\\]+)`)
-	killDetailsRe          = regexp.MustCompile(`\s*\d{1,2}:\d{2}\s+Kill: \d+ \d+ \d+: ([^ ]+) killed ([^ ]+(?: [^ ]+)*) by ([^ ]+)`)
-	killSubMatchRe         = regexp.MustCompile(`:\s+\d+\s+\d+\s+\d+:\s+(.+?)\skilled\s(.+?)\sby\s(\S+)$`)
-	shutDownGameRe         = regexp.MustCompile(`^\s*\d{1,3}:\d{2}\s+ShutdownGame:$`)
-	unknownReasonEndGameRe = regexp.MustCompile(`^.*\d+\s+0:00`)
+	"strings"
 )
 
+// LogDigesterHandler aggregates the events of a match into it. Each handler
+// takes the events it knows of and hands the others to the next one.
 type LogDigesterHandler interface {
-	Handle(logLine string, match *match.Match) error
+	Handle(e event.Event, match *match.Match) error
 }
 
 type (
@@ -31,10 +25,38 @@ type (
 		generalLogDigesterHandler
 	}
 
+	SessionHandler struct {
+		generalLogDigesterHandler
+	}
+
+	FlagHandler struct {
+		generalLogDigesterHandler
+	}
+
+	ItemHandler struct {
+		generalLogDigesterHandler
+	}
+
 	KillDetailsHandler struct {
 		generalLogDigesterHandler
 	}
 
+	ChatHandler struct {
+		generalLogDigesterHandler
+	}
+
+	ExitHandler struct {
+		generalLogDigesterHandler
+	}
+
+	TeamScoreHandler struct {
+		generalLogDigesterHandler
+	}
+
+	ScoreHandler struct {
+		generalLogDigesterHandler
+	}
+
 	EndGameHandler struct {
 		generalLogDigesterHandler
 	}
@@ -44,9 +66,9 @@ func (h *generalLogDigesterHandler) SetNext(handler LogDigesterHandler) {
 	h.Next = handler
 }
 
-func (h *generalLogDigesterHandler) handleNext(logLine string, match *match.Match) error {
+func (h *generalLogDigesterHandler) handleNext(e event.Event, match *match.Match) error {
 	if h.Next != nil {
-		return h.Next.Handle(logLine, match)
+		return h.Next.Handle(e, match)
 	}
 
 	return nil
@@ -56,108 +78,253 @@ func NewInitGameHandler() *InitGameHandler {
 	return &InitGameHandler{}
 }
 
-func (h *InitGameHandler) Handle(logLine string, match *match.Match) error {
-	if initGameRe.MatchString(logLine) {
-		if !match.InProgress {
-			match.InProgress = true
+func (h *InitGameHandler) EventTypes() []string {
+	return []string{event.TypeInitGame}
+}
 
-			return nil
-		}
+func (h *InitGameHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	initGame, ok := e.(*event.InitGameEvent)
+	if !ok {
+		return h.handleNext(e, gameMatch)
+	}
 
-		match.Done = true
+	if !gameMatch.InProgress {
+		gameMatch.InProgress = true
+		gameMatch.Settings = match.NewMatchSettings(initGame.Settings)
+		gameMatch.Start(initGame.At)
 
 		return nil
 	}
 
-	return h.handleNext(logLine, match)
+	gameMatch.Done = true
+
+	return nil
 }
 
 func NewAddPlayerHandler() *AddPlayerHandler {
 	return &AddPlayerHandler{}
 }
 
-func (h *AddPlayerHandler) Handle(logLine string, match *match.Match) error {
-	values := clientUserInfoRe.FindStringSubmatch(logLine)
-	if len(values) > 0 {
-		player := values[1]
+func (h *AddPlayerHandler) EventTypes() []string {
+	return []string{event.TypeUserinfo}
+}
+
+func (h *AddPlayerHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	if userinfo, ok := e.(*event.UserinfoEvent); ok {
+		gameMatch.SetPlayerName(userinfo.ClientID, userinfo.Name)
 
-		_, ok := match.PlayersInGame[player]
-		if !ok {
-			match.Players = append(match.Players, player)
-			match.PlayersInGame[player] = true
-			match.AddKillStats(player)
+		if team, ok := userinfo.Team(); ok {
+			gameMatch.SetPlayerTeam(userinfo.ClientID, match.Team(team), userinfo.At)
 		}
 	}
 
-	return h.handleNext(logLine, match)
+	return h.handleNext(e, gameMatch)
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{}
+}
+
+func (h *SessionHandler) EventTypes() []string {
+	return []string{event.TypeClientConnect, event.TypeClientBegin, event.TypeClientDisconnect}
+}
+
+func (h *SessionHandler) Handle(e event.Event, match *match.Match) error {
+	switch session := e.(type) {
+	case *event.ClientConnectEvent:
+		match.ConnectClient(session.ClientID, session.At)
+	case *event.ClientBeginEvent:
+		match.BeginClient(session.ClientID, session.At)
+	case *event.ClientDisconnectEvent:
+		match.DisconnectClient(session.ClientID, session.At)
+	default:
+		return h.handleNext(e, match)
+	}
+
+	return nil
+}
+
+func NewFlagHandler() *FlagHandler {
+	return &FlagHandler{}
+}
+
+func (h *FlagHandler) EventTypes() []string {
+	return []string{event.TypeItem, event.TypeKill}
+}
+
+// Handle follows the CTF flags through flag touches and kills of flag
+// carriers. Both events are passed on, as they are still item pickups and
+// kills to the rest of the chain.
+func (h *FlagHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	switch flagEvent := e.(type) {
+	case *event.ItemEvent:
+		switch strings.TrimPrefix(flagEvent.Item, "team_CTF_") {
+		case "redflag":
+			gameMatch.TouchFlag(flagEvent.ClientID, match.TeamRed, flagEvent.At)
+		case "blueflag":
+			gameMatch.TouchFlag(flagEvent.ClientID, match.TeamBlue, flagEvent.At)
+		}
+	case *event.KillEvent:
+		gameMatch.KillFlagCarrier(flagEvent.KillerID, flagEvent.VictimID, flagEvent.Means, flagEvent.At)
+	}
+
+	return h.handleNext(e, gameMatch)
+}
+
+func NewItemHandler() *ItemHandler {
+	return &ItemHandler{}
+}
+
+func (h *ItemHandler) EventTypes() []string {
+	return []string{event.TypeItem}
+}
+
+func (h *ItemHandler) Handle(e event.Event, match *match.Match) error {
+	item, ok := e.(*event.ItemEvent)
+	if !ok {
+		return h.handleNext(e, match)
+	}
+
+	match.PickUpItem(item.ClientID, item.Item, item.At)
+
+	return nil
 }
 
 func NewKillDetailsHandler() *KillDetailsHandler {
 	return &KillDetailsHandler{}
 }
 
-func (h *KillDetailsHandler) Handle(logLine string, match *match.Match) error {
-	killerPiece, killedPlayerPiece, reasonPiece := 1, 2, 3
-	matches := killDetailsRe.FindStringSubmatch(logLine)
-	if len(matches) == 0 {
-		matches = killSubMatchRe.FindStringSubmatch(logLine)
+func (h *KillDetailsHandler) EventTypes() []string {
+	return []string{event.TypeKill}
+}
+
+func (h *KillDetailsHandler) Handle(e event.Event, match *match.Match) error {
+	kill, ok := e.(*event.KillEvent)
+	if !ok {
+		return h.handleNext(e, match)
 	}
-	if len(matches) > 3 {
-		match.AddKillAndMeans(
-			matches[killerPiece],
-			matches[killedPlayerPiece],
-			matches[reasonPiece],
-		)
 
-		return nil
+	match.AddKill(matchKill(kill))
+
+	return nil
+}
+
+func NewChatHandler() *ChatHandler {
+	return &ChatHandler{}
+}
+
+func (h *ChatHandler) EventTypes() []string {
+	return []string{event.TypeSay}
+}
+
+func (h *ChatHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	say, ok := e.(*event.SayEvent)
+	if !ok {
+		return h.handleNext(e, gameMatch)
+	}
+
+	channel := match.ChatAll
+	if say.Team {
+		channel = match.ChatTeam
+	}
+
+	gameMatch.Say(channel, say.Text, say.At)
+
+	return nil
+}
+
+func NewExitHandler() *ExitHandler {
+	return &ExitHandler{}
+}
+
+func (h *ExitHandler) EventTypes() []string {
+	return []string{event.TypeExit}
+}
+
+func (h *ExitHandler) Handle(e event.Event, match *match.Match) error {
+	exit, ok := e.(*event.ExitEvent)
+	if !ok {
+		return h.handleNext(e, match)
 	}
 
-	return h.handleNext(logLine, match)
+	match.SetExitReason(exit.Reason)
+
+	return nil
+}
+
+func NewTeamScoreHandler() *TeamScoreHandler {
+	return &TeamScoreHandler{}
+}
+
+func (h *TeamScoreHandler) EventTypes() []string {
+	return []string{event.TypeTeamScore}
+}
+
+func (h *TeamScoreHandler) Handle(e event.Event, match *match.Match) error {
+	teamScore, ok := e.(*event.TeamScoreEvent)
+	if !ok {
+		return h.handleNext(e, match)
+	}
+
+	match.SetTeamScore(teamScore.Red, teamScore.Blue)
+
+	return nil
+}
+
+func NewScoreHandler() *ScoreHandler {
+	return &ScoreHandler{}
+}
+
+func (h *ScoreHandler) EventTypes() []string {
+	return []string{event.TypeScore}
+}
+
+func (h *ScoreHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	score, ok := e.(*event.ScoreEvent)
+	if !ok {
+		return h.handleNext(e, gameMatch)
+	}
+
+	gameMatch.AddScore(match.ScoreEntry{
+		Player:   score.Player,
+		Score:    score.Score,
+		Ping:     score.Ping,
+		ClientID: score.ClientID,
+	})
+
+	return nil
 }
 
 func NewEndGameHandler() *EndGameHandler {
 	return &EndGameHandler{}
 }
 
-func (h *EndGameHandler) Handle(logLine string, match *match.Match) error {
-	if shutDownGameRe.MatchString(logLine) || unknownReasonEndGameRe.MatchString(logLine) {
-		match.InProgress = false
-		match.Done = true
+func (h *EndGameHandler) EventTypes() []string {
+	return []string{event.TypeShutdownGame, event.TypeRestart}
+}
+
+func (h *EndGameHandler) Handle(e event.Event, match *match.Match) error {
+	if event.EndsMatch(e) {
+		// the restart event has no valid game time, so the match closes its
+		// sessions at the last time it has seen.
+		match.Finish(e.Time())
 	}
 
 	return nil
 }
 
+// LoadLogsDigester chains the handlers of DefaultRegistry.
 func LoadLogsDigester() LogDigesterHandler {
-	endGameHandler := NewEndGameHandler()
-
-	killDetailsHandler := NewKillDetailsHandler()
-	killDetailsHandler.SetNext(endGameHandler)
-
-	addPlayerHandler := NewAddPlayerHandler()
-	addPlayerHandler.SetNext(killDetailsHandler)
-
-	initGameHandler := NewInitGameHandler()
-	initGameHandler.SetNext(addPlayerHandler)
-
-	return initGameHandler
-}
-
-func GatherLines(logLine string) (string, bool) {
-	switch {
-	case initGameRe.MatchString(logLine):
-		return logLine, false
-	case clientUserInfoRe.MatchString(logLine):
-		return logLine, false
-	case killDetailsRe.MatchString(logLine):
-		return logLine, false
-	case killSubMatchRe.MatchString(logLine):
-		return logLine, false
-	case shutDownGameRe.MatchString(logLine):
-		return logLine, true
-	case unknownReasonEndGameRe.MatchString(logLine):
-		return logLine, true
-	default:
-		return "", false
+	return DefaultRegistry().Digester()
+}
+
+func matchKill(kill *event.KillEvent) match.KillEvent {
+	return match.KillEvent{
+		At:       kill.At,
+		KillerID: kill.KillerID,
+		VictimID: kill.VictimID,
+		Killer:   kill.Killer,
+		Victim:   kill.Victim,
+		Means:    kill.Means,
 	}
 }
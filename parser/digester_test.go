@@ -3,19 +3,32 @@ package parser
 import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"log-parser/event"
 	match "log-parser/match"
 	"testing"
 )
 
+// handleLine lexes logLine and hands its event to h, skipping lines that carry
+// no event as the parser does.
+func handleLine(h LogDigesterHandler, logLine string, match *match.Match) error {
+	e, ok := event.Lex(logLine, 1)
+	if !ok {
+		return nil
+	}
+
+	return h.Handle(e, match)
+}
+
 func TestInitGameHandler_Handle(t *testing.T) {
 	type args struct {
 		logLine string
 		match   *match.Match
 	}
 	tests := []struct {
-		name    string
-		args    args
-		wantErr assert.ErrorAssertionFunc
+		name         string
+		args         args
+		wantSettings match.MatchSettings
+		wantErr      assert.ErrorAssertionFunc
 	}{
 		{
 			name: "should successfully parse a init game log entry",
@@ -23,6 +36,16 @@ func TestInitGameHandler_Handle(t *testing.T) {
 				logLine: "  0:00 InitGame: \\sv_floodProtect\\1\\sv_maxPing\\0\\sv_minPing\\0\\sv_maxRate\\10000\\sv_minRate\\0\\sv_hostname\\Code Miner Server\\g_gametype\\0\\sv_privateClients\\2\\sv_maxclients\\16\\sv_allowDownload\\0\\dmflags\\0\\fraglimit\\20\\timelimit\\15\\g_maxGameClients\\0\\capturelimit\\8\\version\\ioq3 1.36 linux-x86_64 Apr 12 2009\\protocol\\68\\mapname\\q3dm17\\gamename\\baseq3\\g_needpass\\0",
 				match:   match.NewMatch(),
 			},
+			wantSettings: match.MatchSettings{
+				MapName:      "q3dm17",
+				GameType:     match.GameTypeFreeForAll,
+				FragLimit:    20,
+				TimeLimit:    15,
+				CaptureLimit: 8,
+				Hostname:     "Code Miner Server",
+				Version:      "ioq3 1.36 linux-x86_64 Apr 12 2009",
+				Protocol:     68,
+			},
 			wantErr: assert.NoError,
 		},
 		{
@@ -31,6 +54,16 @@ func TestInitGameHandler_Handle(t *testing.T) {
 				logLine: " 16:53 InitGame: \\capturelimit\\8\\g_maxGameClients\\0\\timelimit\\15\\fraglimit\\20\\dmflags\\0\\bot_minplayers\\0\\sv_allowDownload\\0\\sv_maxclients\\16\\sv_privateClients\\2\\g_gametype\\4\\sv_hostname\\Code Miner Server\\sv_minRate\\0\\sv_maxRate\\10000\\sv_minPing\\0\\sv_maxPing\\0\\sv_floodProtect\\1\\version\\ioq3 1.36 linux-x86_64 Apr 12 2009\\protocol\\68\\mapname\\Q3TOURNEY6_CTF\\gamename\\baseq3\\g_needpass\\0",
 				match:   match.NewMatch(),
 			},
+			wantSettings: match.MatchSettings{
+				MapName:      "Q3TOURNEY6_CTF",
+				GameType:     match.GameTypeCaptureTheFlag,
+				FragLimit:    20,
+				TimeLimit:    15,
+				CaptureLimit: 8,
+				Hostname:     "Code Miner Server",
+				Version:      "ioq3 1.36 linux-x86_64 Apr 12 2009",
+				Protocol:     68,
+			},
 			wantErr: assert.NoError,
 		},
 		{
@@ -39,17 +72,36 @@ func TestInitGameHandler_Handle(t *testing.T) {
 				logLine: "981:27 InitGame: \\capturelimit\\8\\g_maxGameClients\\0\\timelimit\\15\\fraglimit\\20\\dmflags\\0\\bot_minplayers\\0\\sv_allowDownload\\0\\sv_maxclients\\16\\sv_privateClients\\2\\g_gametype\\4\\sv_hostname\\Code Miner Server\\sv_minRate\\0\\sv_maxRate\\10000\\sv_minPing\\0\\sv_maxPing\\0\\sv_floodProtect\\1\\version\\ioq3 1.36 linux-x86_64 Apr 12 2009\\protocol\\68\\mapname\\Q3TOURNEY6_CTF\\gamename\\baseq3\\g_needpass\\0",
 				match:   match.NewMatch(),
 			},
+			wantSettings: match.MatchSettings{
+				MapName:      "Q3TOURNEY6_CTF",
+				GameType:     match.GameTypeCaptureTheFlag,
+				FragLimit:    20,
+				TimeLimit:    15,
+				CaptureLimit: 8,
+				Hostname:     "Code Miner Server",
+				Version:      "ioq3 1.36 linux-x86_64 Apr 12 2009",
+				Protocol:     68,
+			},
 			wantErr: assert.NoError,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewInitGameHandler()
-			tt.wantErr(t, h.Handle(tt.args.logLine, tt.args.match), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, tt.args.match))
+			tt.wantErr(t, handleLine(h, tt.args.logLine, tt.args.match), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, tt.args.match))
 
 			m := tt.args.match
 
 			assert.Equal(t, true, m.InProgress)
+			assert.Equal(t, tt.wantSettings.MapName, m.Settings.MapName)
+			assert.Equal(t, tt.wantSettings.GameType, m.Settings.GameType)
+			assert.Equal(t, tt.wantSettings.FragLimit, m.Settings.FragLimit)
+			assert.Equal(t, tt.wantSettings.TimeLimit, m.Settings.TimeLimit)
+			assert.Equal(t, tt.wantSettings.CaptureLimit, m.Settings.CaptureLimit)
+			assert.Equal(t, tt.wantSettings.Hostname, m.Settings.Hostname)
+			assert.Equal(t, tt.wantSettings.Version, m.Settings.Version)
+			assert.Equal(t, tt.wantSettings.Protocol, m.Settings.Protocol)
+			assert.Equal(t, tt.wantSettings.MapName, m.Settings.Raw["mapname"])
 		})
 	}
 }
@@ -185,7 +237,7 @@ func TestAddPlayerHandler_Handle(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewAddPlayerHandler()
 			m := tt.args.match()
-			tt.wantErr(t, h.Handle(tt.args.logLine, m), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, m))
+			tt.wantErr(t, handleLine(h, tt.args.logLine, m), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, m))
 
 			assert.Equal(t, len(tt.wantMatch.Players), len(m.Players))
 			assert.Equal(t, tt.wantMatch.Players[0], m.Players[0])
@@ -361,7 +413,7 @@ func TestKillDetailsHandler_Handle(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewKillDetailsHandler()
 			m := tt.args.match()
-			tt.wantErr(t, h.Handle(tt.args.logLine, m), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, m))
+			tt.wantErr(t, handleLine(h, tt.args.logLine, m), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, m))
 
 			assert.Equal(t, len(tt.wantMatch.Players), len(m.Players))
 			assert.Equal(t, tt.wantMatch.Players[0], m.Players[0])
@@ -576,7 +628,7 @@ func TestEndGameHandler_Handle(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			h := NewEndGameHandler()
-			tt.wantErr(t, h.Handle(tt.args.logLine, tt.args.match), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, tt.args.match))
+			tt.wantErr(t, handleLine(h, tt.args.logLine, tt.args.match), fmt.Sprintf("Handle(%v, %v)", tt.args.logLine, tt.args.match))
 
 			assert.Equal(t, tt.wantMatch.Done, tt.args.match.Done)
 			assert.Equal(t, tt.wantMatch.InProgress, tt.args.match.InProgress)
@@ -602,3 +654,238 @@ func TestEndGameHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		logLines       []string
+		wantSessions   []match.Session
+		wantTimePlayed event.GameTime
+		wantLeftEarly  bool
+	}{
+		{
+			name: "should open a session when the client connects and begins",
+			logLines: []string{
+				" 20:38 ClientConnect: 2",
+				" 20:38 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\0\\model\\uriel/zael\\hmodel\\uriel/zael",
+				" 20:40 ClientBegin: 2",
+			},
+			wantSessions: []match.Session{
+				{ConnectedAt: event.NewGameTime(20, 38)},
+			},
+			wantTimePlayed: event.NewGameTime(0, 20),
+		},
+		{
+			name: "should close the session when the client disconnects",
+			logLines: []string{
+				"981:06 ClientConnect: 2",
+				"981:06 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\3\\model\\sarge/krusade",
+				"981:07 ClientBegin: 2",
+				"981:10 ClientDisconnect: 2",
+			},
+			wantSessions: []match.Session{
+				{ConnectedAt: event.NewGameTime(981, 6)},
+			},
+			wantTimePlayed: event.NewGameTime(0, 3),
+			wantLeftEarly:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := LoadLogsDigester()
+			m := match.NewMatch()
+
+			for _, logLine := range tt.logLines {
+				assert.NoError(t, handleLine(h, logLine, m))
+			}
+			m.CloseSessions(event.NewGameTime(21, 0))
+
+			player := m.PlayerByID(2)
+			if assert.NotNil(t, player) {
+				assert.Equal(t, len(tt.wantSessions), len(player.Sessions))
+				for i, wantSession := range tt.wantSessions {
+					assert.Equal(t, wantSession.ConnectedAt, player.Sessions[i].ConnectedAt)
+				}
+
+				assert.Equal(t, tt.wantTimePlayed, player.TimePlayed)
+				assert.Equal(t, tt.wantLeftEarly, player.LeftEarly)
+			}
+		})
+	}
+}
+
+func TestExitHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		logLine        string
+		wantExitReason string
+	}{
+		{
+			name:           "should record a match that ended by the frag limit",
+			logLine:        " 11:57 Exit: Fraglimit hit.",
+			wantExitReason: match.ExitFragLimit,
+		},
+		{
+			name:           "should record a match that ended by the time limit",
+			logLine:        "981:39 Exit: Timelimit hit.",
+			wantExitReason: match.ExitTimeLimit,
+		},
+		{
+			name:           "should record a match that ended by the capture limit",
+			logLine:        " 10:12 Exit: Capturelimit hit.",
+			wantExitReason: match.ExitCaptureLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewExitHandler()
+			m := match.NewMatch()
+			m.InProgress = true
+
+			assert.NoError(t, handleLine(h, tt.logLine, m))
+			assert.Equal(t, tt.wantExitReason, m.ExitReason)
+		})
+	}
+
+	t.Run("should record a match that ended without an exit line as aborted", func(t *testing.T) {
+		h := LoadLogsDigester()
+		m := match.NewMatch()
+
+		assert.NoError(t, handleLine(h, "  0:00 InitGame: \\mapname\\q3dm17", m))
+		assert.NoError(t, handleLine(h, "  1:47 ShutdownGame:", m))
+		assert.Equal(t, match.ExitAborted, m.ExitReason)
+	})
+}
+
+func TestScoreHandler_Handle(t *testing.T) {
+	tests := []struct {
+		name      string
+		logLine   string
+		wantScore match.ScoreEntry
+	}{
+		{
+			name:      "should add the score line to the scoreboard",
+			logLine:   " 11:57 score: 20  ping: 4  client: 4 Zeh",
+			wantScore: match.ScoreEntry{Player: "Zeh", Score: 20, Ping: 4, ClientID: 4},
+		},
+		{
+			name:      "should add a negative score with a player name with spaces",
+			logLine:   " 31:53 score: -4  ping: 0  client: 5 Assasinu Credi",
+			wantScore: match.ScoreEntry{Player: "Assasinu Credi", Score: -4, Ping: 0, ClientID: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewScoreHandler()
+			m := match.NewMatch()
+
+			assert.NoError(t, handleLine(h, tt.logLine, m))
+			assert.Equal(t, []match.ScoreEntry{tt.wantScore}, m.Scoreboard)
+		})
+	}
+}
+
+func TestAddPlayerHandler_Handle_Team(t *testing.T) {
+	tests := []struct {
+		name     string
+		logLines []string
+		wantTeam match.Team
+		wantLen  int
+	}{
+		{
+			name: "should put the player on the team of the user info",
+			logLines: []string{
+				" 2:33 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\1\\model\\uriel/zael\\hmodel\\uriel/zael",
+			},
+			wantTeam: match.TeamRed,
+			wantLen:  1,
+		},
+		{
+			name: "should record a spectator switching to a team",
+			logLines: []string{
+				" 1:00 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\3\\model\\uriel/zael\\hmodel\\uriel/zael",
+				" 1:07 ClientUserinfoChanged: 2 n\\Isgalamido\\t\\2\\model\\uriel/zael\\hmodel\\uriel/zael",
+			},
+			wantTeam: match.TeamBlue,
+			wantLen:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAddPlayerHandler()
+			m := match.NewMatch()
+
+			for _, logLine := range tt.logLines {
+				assert.NoError(t, handleLine(h, logLine, m))
+			}
+
+			player := m.PlayerByID(2)
+			assert.Equal(t, tt.wantTeam, player.Team)
+			assert.Len(t, player.TeamChanges, tt.wantLen)
+		})
+	}
+}
+
+func TestTeamScoreHandler_Handle(t *testing.T) {
+	h := NewTeamScoreHandler()
+	m := match.NewMatch()
+
+	assert.NoError(t, handleLine(h, " 10:12 red:8  blue:6", m))
+	assert.Equal(t, &match.TeamScore{Red: 8, Blue: 6}, m.TeamScore)
+}
+
+func TestFlagHandler_Handle(t *testing.T) {
+	h := NewFlagHandler()
+	m := match.NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerTeam(2, match.TeamRed, 0)
+	m.SetPlayerName(5, "Oootsimo")
+	m.SetPlayerTeam(5, match.TeamBlue, 0)
+
+	logLines := []string{
+		"  1:19 Item: 5 team_CTF_redflag",
+		"  1:25 Kill: 2 5 10: Isgalamido killed Oootsimo by MOD_RAILGUN",
+		"  1:29 Item: 2 team_CTF_redflag",
+	}
+	for _, logLine := range logLines {
+		assert.NoError(t, handleLine(h, logLine, m))
+	}
+
+	assert.Equal(t, map[string]*match.FlagStats{
+		"Isgalamido": {Returns: 1, CarrierKills: 1},
+		"Oootsimo":   {Pickups: 1},
+	}, m.Flags)
+}
+
+func TestItemHandler_Handle(t *testing.T) {
+	h := NewItemHandler()
+	m := match.NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+
+	logLines := []string{
+		" 20:42 Item: 2 item_armor_body",
+		" 20:45 Item: 2 weapon_rocketlauncher",
+		" 20:47 Item: 2 item_armor_body",
+	}
+	for _, logLine := range logLines {
+		assert.NoError(t, handleLine(h, logLine, m))
+	}
+
+	assert.Equal(t, map[string]map[string]int{
+		"Isgalamido": {"item_armor_body": 2, "weapon_rocketlauncher": 1},
+	}, m.Items)
+}
+
+func TestChatHandler_Handle(t *testing.T) {
+	h := NewChatHandler()
+	m := match.NewMatch()
+	m.SetPlayerName(3, "Isgalamido")
+
+	assert.NoError(t, handleLine(h, "981:26 say: Isgalamido: team blue", m))
+	assert.NoError(t, handleLine(h, "981:27 sayteam: Isgalamido: cover me", m))
+
+	assert.Equal(t, []match.ChatMessage{
+		{At: event.NewGameTime(981, 26), ClientID: 3, Player: "Isgalamido", Channel: match.ChatAll, Text: "team blue"},
+		{At: event.NewGameTime(981, 27), ClientID: 3, Player: "Isgalamido", Channel: match.ChatTeam, Text: "cover me"},
+	}, m.Chat)
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	Strict ErrorMode = iota
+	Lenient
+)
+
+type (
+	ErrorMode int
+
+	ParseError struct {
+		File    string
+		Line    int
+		Raw     string
+		Handler string
+		Err     error
+	}
+
+	ParseErrors []*ParseError
+
+	HandlerError struct {
+		Handler string
+		Err     error
+	}
+)
+
+func NewHandlerError(handler string, err error) *HandlerError {
+	return &HandlerError{
+		Handler: handler,
+		Err:     err,
+	}
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Handler, e.Err)
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("parsing ")
+	if e.File != "" {
+		sb.WriteString(e.File)
+	} else {
+		sb.WriteString("log")
+	}
+
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, " line %d", e.Line)
+	}
+
+	if e.Handler != "" {
+		fmt.Fprintf(&sb, " in %s", e.Handler)
+	}
+
+	fmt.Fprintf(&sb, ": %v", e.Err)
+
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func (e ParseErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no parse errors"
+	case 1:
+		return e[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e[0].Error(), len(e)-1)
+	}
+}
+
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log-parser/match"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = 250 * time.Millisecond
+
+	// replacedCheckSize is how many of the last bytes read are compared to
+	// the file to tell it was rewritten in place.
+	replacedCheckSize = 64
+)
+
+// ErrFollowAnchor is returned by Follow when given a wall-clock anchor or a
+// date range, as anchoring needs the whole log and a followed log never ends.
+var ErrFollowAnchor = errors.New("following a log cannot anchor matches to the wall clock")
+
+// errLogReplaced stops the scanner of a followed log when the file starts
+// over, so the lines are gathered again from the top of the new content.
+var errLogReplaced = errors.New("the followed log was replaced")
+
+type (
+	Follower struct {
+		matches    chan *match.Match
+		cfg        *config
+		inProgress *inProgressMatch
+		err        error
+	}
+
+	inProgressMatch struct {
+		mu       sync.Mutex
+		gathered gatheredMatch
+	}
+
+	followReader struct {
+		ctx          context.Context
+		path         string
+		file         *os.File
+		offset       int64
+		tail         []byte
+		pollInterval time.Duration
+	}
+)
+
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.pollInterval = interval
+		}
+	}
+}
+
+// Follow parses the log file at filepath and keeps reading it as the server
+// appends to it, sending each match on Matches as soon as it ends. Following
+// survives the file being truncated or replaced by log rotation: the match
+// being played when it happens is dropped and lines are counted from the top
+// of the new content again. Following stops when ctx is cancelled. The wall-clock options are rejected with
+// ErrFollowAnchor.
+func Follow(ctx context.Context, filepath string, opts ...Option) (*Follower, error) {
+	opts = append([]Option{WithSourceName(filepath)}, opts...)
+	cfg := newConfig(opts...)
+	if cfg.anchor != nil || cfg.dateRange != nil {
+		return nil, ErrFollowAnchor
+	}
+	cfg.inProgress = &inProgressMatch{}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("reading the log file: %w", err)
+	}
+
+	f := &Follower{
+		matches:    make(chan *match.Match),
+		cfg:        cfg,
+		inProgress: cfg.inProgress,
+	}
+
+	r := &followReader{
+		ctx:          ctx,
+		path:         filepath,
+		file:         file,
+		pollInterval: cfg.pollInterval,
+	}
+
+	go func() {
+		defer close(f.matches)
+
+		err := parse(ctx, r, cfg, func(gameMatch *match.Match) {
+			select {
+			case f.matches <- gameMatch:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			f.err = err
+		}
+	}()
+
+	return f, nil
+}
+
+func (f *Follower) Matches() <-chan *match.Match {
+	return f.matches
+}
+
+// Snapshot digests the lines gathered so far for the match that has not
+// ended yet. It returns nil when no match is in progress.
+func (f *Follower) Snapshot() *match.Match {
+	gathered := f.inProgress.get()
+	if len(gathered.events) == 0 {
+		return nil
+	}
+
+	gameMatch, _ := digestMatch(context.Background(), f.cfg, gathered)
+
+	return gameMatch
+}
+
+// Err returns the error that stopped the follower. It must only be called
+// once the Matches channel is closed.
+func (f *Follower) Err() error {
+	return f.err
+}
+
+func (p *inProgressMatch) set(gathered gatheredMatch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gathered = gathered
+}
+
+func (p *inProgressMatch) get() gatheredMatch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.gathered
+}
+
+// Read blocks until the file grows instead of returning io.EOF, and returns
+// errLogReplaced when the file starts over. The file is closed once Read
+// returns any other error, since the scanner stops reading then.
+func (r *followReader) Read(b []byte) (int, error) {
+	n, err := r.read(b)
+	if err != nil && !errors.Is(err, errLogReplaced) {
+		r.close()
+	}
+
+	return n, err
+}
+
+func (r *followReader) read(b []byte) (int, error) {
+	for {
+		n, err := r.file.Read(b)
+		r.offset += int64(n)
+		if n > 0 {
+			r.tail = append(r.tail, b[:n]...)
+			r.tail = r.tail[max(0, len(r.tail)-replacedCheckSize):]
+
+			return n, nil
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+
+		// checked after waiting, before reading what was written meanwhile,
+		// so a file truncated and grown past the offset is not read on from
+		// the middle.
+		reopened, err := r.reopenIfReplaced()
+		if err != nil {
+			return 0, err
+		}
+
+		if reopened {
+			return 0, errLogReplaced
+		}
+	}
+}
+
+// reopenIfReplaced starts reading from the beginning again when the file was
+// truncated, or from the new file when the path now points to another one.
+// A file rewritten in place is told by the last bytes read no longer being
+// where they were, which misses a rewrite that puts the same bytes there.
+func (r *followReader) reopenIfReplaced() (bool, error) {
+	pathInfo, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// the file was moved away and the new one was not created yet.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	fileInfo, err := r.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if !os.SameFile(pathInfo, fileInfo) {
+		file, err := os.Open(r.path)
+		if err != nil {
+			return false, err
+		}
+
+		r.close()
+		r.file = file
+		r.offset = 0
+		r.tail = nil
+
+		return true, nil
+	}
+
+	truncated := pathInfo.Size() < r.offset
+	if !truncated {
+		truncated, err = r.rewritten()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if truncated {
+		_, err = r.file.Seek(0, io.SeekStart)
+		if err != nil {
+			return false, err
+		}
+
+		r.offset = 0
+		r.tail = nil
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// rewritten tells whether the last bytes read have changed in the file.
+func (r *followReader) rewritten() (bool, error) {
+	if len(r.tail) == 0 {
+		return false, nil
+	}
+
+	tail := make([]byte, len(r.tail))
+	n, err := r.file.ReadAt(tail, r.offset-int64(len(tail)))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return !bytes.Equal(tail[:n], r.tail), nil
+}
+
+func (r *followReader) close() {
+	_ = r.file.Close()
+}
@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log-parser/match"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	followInitGameLine = `  0:00 InitGame: \sv_hostname\Code Miner Server\g_gametype\0\mapname\q3dm17` + "\n"
+	followPlayerLine   = `  0:25 ClientUserinfoChanged: 2 n\Isgalamido\t\0\model\sarge` + "\n"
+	followKillLine     = `  0:30 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT` + "\n"
+	followShutdownLine = `  1:47 ShutdownGame:` + "\n"
+)
+
+func appendToFile(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, line := range lines {
+		if _, err = file.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func receiveMatch(t *testing.T, f *Follower) *match.Match {
+	t.Helper()
+
+	select {
+	case m, ok := <-f.Matches():
+		if !ok {
+			t.Fatalf("matches channel closed: %v", f.Err())
+		}
+
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a match")
+	}
+
+	return nil
+}
+
+func TestFollow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "games.log")
+	appendToFile(t, path, followInitGameLine, followPlayerLine, followKillLine, followShutdownLine)
+
+	f, err := Follow(ctx, path, WithPollInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should emit the matches already present in the file", func(t *testing.T) {
+		m := receiveMatch(t, f)
+		assert.Equal(t, 1, m.Index)
+		assert.Equal(t, 1, m.TotalKills)
+	})
+
+	t.Run("should expose the in-progress match and emit it once it ends", func(t *testing.T) {
+		appendToFile(t, path, followInitGameLine, followPlayerLine, followKillLine, followKillLine)
+
+		assert.Eventually(t, func() bool {
+			snapshot := f.Snapshot()
+			return snapshot != nil && snapshot.TotalKills == 2
+		}, 5*time.Second, 5*time.Millisecond)
+
+		appendToFile(t, path, followShutdownLine)
+
+		m := receiveMatch(t, f)
+		assert.Equal(t, 2, m.Index)
+		assert.Equal(t, 2, m.TotalKills)
+		assert.Nil(t, f.Snapshot())
+	})
+
+	t.Run("should start over when the file is truncated, dropping the match cut short", func(t *testing.T) {
+		appendToFile(t, path, followInitGameLine, followPlayerLine, followKillLine)
+
+		assert.Eventually(t, func() bool {
+			snapshot := f.Snapshot()
+			return snapshot != nil && snapshot.TotalKills == 1
+		}, 5*time.Second, 5*time.Millisecond)
+
+		if err := os.Truncate(path, 0); err != nil {
+			t.Fatal(err)
+		}
+		appendToFile(t, path, followInitGameLine, followShutdownLine)
+
+		m := receiveMatch(t, f)
+		assert.Equal(t, 3, m.Index)
+		assert.Equal(t, 0, m.TotalKills)
+		assert.Equal(t, 1, m.StartLine)
+		assert.Equal(t, 2, m.EndLine)
+	})
+
+	t.Run("should keep reading the new file after a log rotation", func(t *testing.T) {
+		if err := os.Rename(path, path+".1"); err != nil {
+			t.Fatal(err)
+		}
+		appendToFile(t, path, followInitGameLine, followPlayerLine, followKillLine, followKillLine, followKillLine, followShutdownLine)
+
+		m := receiveMatch(t, f)
+		assert.Equal(t, 4, m.Index)
+		assert.Equal(t, 3, m.TotalKills)
+	})
+
+	t.Run("should close the matches channel without error when the context is cancelled", func(t *testing.T) {
+		cancel()
+
+		select {
+		case _, ok := <-f.Matches():
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the follower to stop")
+		}
+
+		assert.NoError(t, f.Err())
+	})
+}
+
+func TestFollowReader_reopenIfReplaced(t *testing.T) {
+	tests := []struct {
+		name         string
+		rewrite      func(t *testing.T, path string)
+		wantReopened bool
+		wantOffset   int64
+	}{
+		{
+			name: "should keep the offset when the file was appended to",
+			rewrite: func(t *testing.T, path string) {
+				appendToFile(t, path, followShutdownLine)
+			},
+			wantOffset: int64(len(followInitGameLine + followKillLine)),
+		},
+		{
+			name: "should start over when the file was truncated",
+			rewrite: func(t *testing.T, path string) {
+				if err := os.Truncate(path, 0); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantReopened: true,
+		},
+		{
+			name: "should start over when the file was truncated and grew past the offset",
+			rewrite: func(t *testing.T, path string) {
+				content := followInitGameLine + followPlayerLine + followPlayerLine + followShutdownLine
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantReopened: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "games.log")
+			appendToFile(t, path, followInitGameLine, followKillLine)
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &followReader{ctx: context.Background(), path: path, file: file, pollInterval: time.Millisecond}
+			defer r.close()
+
+			_, err = io.ReadFull(r, make([]byte, len(followInitGameLine+followKillLine)))
+			assert.NoError(t, err)
+
+			tt.rewrite(t, path)
+
+			reopened, err := r.reopenIfReplaced()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReopened, reopened)
+			assert.Equal(t, tt.wantOffset, r.offset)
+		})
+	}
+}
+
+func TestFollow_Anchor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "games.log")
+	appendToFile(t, path, followInitGameLine)
+
+	from := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	for _, opt := range []Option{WithStartTime(from), WithDateRange(from, from.AddDate(0, 0, 1))} {
+		f, err := Follow(context.Background(), path, opt)
+		assert.Nil(t, f)
+		assert.ErrorIs(t, err, ErrFollowAnchor)
+	}
+}
@@ -2,80 +2,406 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"errors"
-	"log"
+	"fmt"
+	"io"
+	"log-parser/event"
 	"log-parser/match"
 	"os"
 	"sync"
+	"time"
 )
 
-func ParseLog(filepath string) ([]*match.Match, error) {
+const (
+	defaultMaxLineSize = 1024 * 1024
+)
+
+type (
+	Option func(*config)
+
+	config struct {
+		maxLineSize  int
+		sourceName   string
+		errorMode    ErrorMode
+		digester     LogDigesterHandler
+		eventTypes   map[string]bool
+		pollInterval time.Duration
+		excludeChat  bool
+		anchor       anchor
+		reference    *referenceLine
+		dateRange    *dateRange
+		inProgress   *inProgressMatch
+		observer     MatchObserver
+	}
+
+	// MatchObserver is handed every completed match with the events it was
+	// digested from, in log order, as soon as the parser produces it. The
+	// match is not anchored to the wall clock yet, as that needs the whole log.
+	// An error stops the parsing.
+	MatchObserver func(gameMatch *match.Match, events []event.Event) error
+
+	gatheredMatch struct {
+		index     int
+		startLine int
+		endLine   int
+		events    []event.Event
+	}
+
+	digestResult struct {
+		index  int
+		match  *match.Match
+		events []event.Event
+		errs   ParseErrors
+	}
+)
+
+func WithMaxLineSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.maxLineSize = size
+		}
+	}
+}
+
+func WithSourceName(name string) Option {
+	return func(c *config) {
+		c.sourceName = name
+	}
+}
+
+func WithErrorMode(mode ErrorMode) Option {
+	return func(c *config) {
+		c.errorMode = mode
+	}
+}
+
+func WithMatchObserver(observer MatchObserver) Option {
+	return func(c *config) {
+		c.observer = observer
+	}
+}
+
+// WithoutChat leaves say and sayteam lines out of the parsed matches, so what
+// players said never reaches a report.
+func WithoutChat() Option {
+	return func(c *config) {
+		c.excludeChat = true
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	c := &config{
+		maxLineSize:  defaultMaxLineSize,
+		errorMode:    Strict,
+		pollInterval: defaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.digester == nil {
+		WithRegistry(DefaultRegistry())(c)
+	}
+
+	return c
+}
+
+// ParseLog parses the log file at filepath. See ParseReader for how errors
+// are reported in each ErrorMode.
+func ParseLog(filepath string, opts ...Option) (matches []*match.Match, err error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return nil, errors.New("reading the log file")
+		return nil, fmt.Errorf("reading the log file: %w", err)
 	}
 	defer func(file *os.File) {
-		err = file.Close()
-		if err != nil {
-			log.Fatalf("closing the file: %s", err.Error())
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf("closing the file: %w", closeErr)
 		}
 	}(file)
 
-	digester := LoadLogsDigester()
+	opts = append([]Option{WithSourceName(filepath)}, opts...)
+
+	return ParseReader(context.Background(), file, opts...)
+}
+
+// ParseReader parses every match read from r. In Strict mode the first
+// *ParseError in log order aborts the parsing. In Lenient mode the failing
+// lines are skipped and the parsed matches are returned together with a
+// ParseErrors value holding every failure. When ctx is cancelled, r is closed
+// if it is an io.Closer, as a Read that never returns would otherwise keep the
+// goroutine reading it alive.
+func ParseReader(ctx context.Context, r io.Reader, opts ...Option) ([]*match.Match, error) {
+	cfg := newConfig(opts...)
 	matches := make([]*match.Match, 0)
 
-	resultStream := make(chan *match.Match)
-	gatheredLinesStream := make(chan []string)
-	lines := make([]string, 0)
+	err := parse(ctx, r, cfg, func(gameMatch *match.Match) {
+		matches = append(matches, gameMatch)
+	})
 
-	go func() {
-		defer close(gatheredLinesStream)
-		sc := bufio.NewScanner(file)
-		for sc.Scan() {
-			logLine, matchLastLine := GatherLines(sc.Text())
-			if logLine != "" {
-				lines = append(lines, logLine)
-			}
+	var parseErrs ParseErrors
+	if err != nil && !errors.As(err, &parseErrs) {
+		return nil, err
+	}
 
-			if matchLastLine {
-				gatheredLinesStream <- lines
-				lines = make([]string, 0)
-			}
-		}
+	matches, anchorErr := anchorMatches(cfg, matches)
+	if anchorErr != nil {
+		return nil, anchorErr
+	}
 
-		if len(lines) > 0 {
-			gatheredLinesStream <- lines
-		}
+	if err != nil {
+		return matches, err
+	}
+
+	return matches, nil
+}
+
+func parse(ctx context.Context, r io.Reader, cfg *config, emit func(*match.Match)) error {
+	if closer, ok := r.(io.Closer); ok {
+		stop := context.AfterFunc(ctx, func() {
+			_ = closer.Close()
+		})
+		defer stop()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	resultStream := make(chan digestResult)
+	gatheredMatchStream := make(chan gatheredMatch)
+	scanErrStream := make(chan error, 1)
+
+	go func() {
+		defer close(gatheredMatchStream)
+		scanErrStream <- gatherLines(ctx, r, cfg, gatheredMatchStream)
 	}()
 
 	var wg sync.WaitGroup
 	go func() {
-		for gatheredLines := range gatheredLinesStream {
+		defer close(resultStream)
+
+		for gathered := range gatheredMatchStream {
 			wg.Add(1)
-			go func(gatheredLines []string) {
+			go func(gathered gatheredMatch) {
 				defer wg.Done()
 
-				gameMatch := match.NewMatch()
-				for _, line := range gatheredLines {
-					err = digester.Handle(line, gameMatch)
-					if err != nil {
-						log.Fatalf("digesting log file: %v", err.Error())
-					}
+				result := digestResult{index: gathered.index, events: gathered.events}
 
-					if gameMatch.Done {
-						resultStream <- gameMatch
-					}
+				gameMatch, errs := digestMatch(ctx, cfg, gathered)
+				if gameMatch.Done {
+					result.match = gameMatch
+				}
+				result.errs = errs
+
+				select {
+				case resultStream <- result:
+				case <-ctx.Done():
 				}
-			}(gatheredLines)
+			}(gathered)
 		}
 
 		wg.Wait()
-		close(resultStream)
 	}()
 
-	for result := range resultStream {
-		matches = append(matches, result)
+	// digest goroutines finish in any order, so results are held back until
+	// every match gathered before them has been emitted.
+	var errs ParseErrors
+	pending := make(map[int]digestResult)
+	next := 1
+	for {
+		select {
+		case result, ok := <-resultStream:
+			if !ok {
+				if err := <-scanErrStream; err != nil {
+					var parseErr *ParseError
+					if cfg.errorMode == Strict || !errors.As(err, &parseErr) {
+						return err
+					}
+
+					errs = append(errs, parseErr)
+				}
+
+				if len(errs) > 0 {
+					return errs
+				}
+
+				return nil
+			}
+
+			pending[result.index] = result
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+
+				delete(pending, next)
+				next++
+
+				if len(result.errs) > 0 {
+					if cfg.errorMode == Strict {
+						return result.errs[0]
+					}
+
+					errs = append(errs, result.errs...)
+				}
+
+				if result.match != nil {
+					if cfg.observer != nil {
+						if err := cfg.observer(result.match, result.events); err != nil {
+							return err
+						}
+					}
+
+					emit(result.match)
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+}
 
-	return matches, nil
+func digestMatch(ctx context.Context, cfg *config, gathered gatheredMatch) (*match.Match, ParseErrors) {
+	var errs ParseErrors
+
+	gameMatch := match.NewMatch()
+	gameMatch.Index = gathered.index
+	gameMatch.StartLine = gathered.startLine
+	gameMatch.EndLine = gathered.endLine
+
+	for _, e := range gathered.events {
+		if ctx.Err() != nil {
+			return gameMatch, errs
+		}
+
+		err := cfg.digester.Handle(e, gameMatch)
+		if err != nil {
+			errs = append(errs, newParseError(cfg, e, err))
+			if cfg.errorMode == Strict {
+				return gameMatch, errs
+			}
+		}
+	}
+
+	return gameMatch, errs
+}
+
+func newParseError(cfg *config, e event.Event, err error) *ParseError {
+	parseErr := &ParseError{
+		File: cfg.sourceName,
+		Line: e.LineNumber(),
+		Raw:  e.RawLine(),
+		Err:  err,
+	}
+
+	var handlerErr *HandlerError
+	if errors.As(err, &handlerErr) {
+		parseErr.Handler = handlerErr.Handler
+		parseErr.Err = handlerErr.Err
+	}
+
+	return parseErr
+}
+
+// gathers tells whether e goes into its match. The events ending a match are
+// always gathered, as they mark where the next one starts.
+func (c *config) gathers(e event.Event) bool {
+	if event.EndsMatch(e) {
+		return true
+	}
+
+	if c.excludeChat && e.Type() == event.TypeSay {
+		return false
+	}
+
+	return c.eventTypes == nil || c.eventTypes[e.Type()]
+}
+
+func gatherLines(ctx context.Context, r io.Reader, cfg *config, gatheredMatchStream chan<- gatheredMatch) error {
+	lineNumber := 0
+	gathered := gatheredMatch{index: 1}
+
+	send := func() error {
+		select {
+		case gatheredMatchStream <- gathered:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		gathered = gatheredMatch{index: gathered.index + 1}
+		if cfg.inProgress != nil {
+			cfg.inProgress.set(gathered)
+		}
+
+		return nil
+	}
+
+	for {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, cfg.maxLineSize)), cfg.maxLineSize)
+		for sc.Scan() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lineNumber++
+
+			e, ok := event.Lex(sc.Text(), lineNumber)
+			ok = ok && cfg.gathers(e)
+
+			if ok {
+				if len(gathered.events) == 0 {
+					gathered.startLine = lineNumber
+				}
+
+				gathered.events = append(gathered.events, e)
+				gathered.endLine = lineNumber
+
+				if cfg.inProgress != nil && !event.EndsMatch(e) {
+					cfg.inProgress.set(gathered)
+				}
+			}
+
+			if cfg.reference != nil {
+				cfg.reference.record(lineNumber, sc.Text(), gathered)
+			}
+
+			if ok && event.EndsMatch(e) {
+				if err := send(); err != nil {
+					return err
+				}
+			}
+		}
+
+		err := sc.Err()
+		if errors.Is(err, errLogReplaced) {
+			// the followed log started over, so the match it cut short is
+			// dropped and lines are counted from the top again.
+			lineNumber = 0
+			gathered = gatheredMatch{index: gathered.index}
+			if cfg.inProgress != nil {
+				cfg.inProgress.set(gathered)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return &ParseError{
+				File: cfg.sourceName,
+				Line: lineNumber + 1,
+				Err:  fmt.Errorf("scanning the log: %w", err),
+			}
+		}
+
+		break
+	}
+
+	if len(gathered.events) > 0 {
+		return send()
+	}
+
+	return nil
 }
@@ -1,10 +1,19 @@
 package parser
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"log-parser/event"
 	"log-parser/match"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_parseLog(t *testing.T) {
@@ -46,6 +55,13 @@ func Test_parseLog(t *testing.T) {
 			},
 			wantMatches: []*match.Match{
 				{
+					Index:      1,
+					StartLine:  2,
+					EndLine:    8,
+					ExitReason: match.ExitTimeLimit,
+					StartedAt:  event.NewGameTime(0, 0),
+					EndedAt:    event.NewGameTime(20, 37),
+					Duration:   event.NewGameTime(20, 37),
 					TotalKills: 0,
 					Players:    []string{"Isgalamido"},
 					Kills: map[string]int{
@@ -59,12 +75,18 @@ func Test_parseLog(t *testing.T) {
 					InProgress: false,
 				},
 				{
+					Index:      2,
+					StartLine:  11,
+					EndLine:    97,
+					ExitReason: match.ExitAborted,
+					StartedAt:  event.NewGameTime(20, 37),
+					EndedAt:    event.NewGameTime(26, 9),
+					Duration:   event.NewGameTime(5, 32),
 					TotalKills: 11,
-					Players:    []string{"Isgalamido", "Dono da Bola", "Mocinha"},
+					Players:    []string{"Isgalamido", "Mocinha"},
 					Kills: map[string]int{
-						"Isgalamido":   -7,
-						"Dono da Bola": 0,
-						"Mocinha":      0,
+						"Isgalamido": -7,
+						"Mocinha":    0,
 					},
 					KillsByMeans: map[string]int{
 						"MOD_TRIGGER_HURT":  7,
@@ -72,19 +94,28 @@ func Test_parseLog(t *testing.T) {
 						"MOD_FALLING":       1,
 					},
 					PlayersInGame: map[string]bool{
-						"Isgalamido":   true,
-						"Dono da Bola": true,
-						"Mocinha":      true,
+						"Isgalamido": true,
+						"Mocinha":    true,
+					},
+					Roster: []*match.Player{
+						{ID: 2, Name: "Isgalamido", Aliases: []string{"Isgalamido"}, Reconnects: 1},
+						{ID: 3, Name: "Mocinha", Aliases: []string{"Dono da Bola", "Mocinha"}, LeftEarly: true},
 					},
 					Done:       true,
 					InProgress: false,
 				},
 				{
+					Index:      3,
+					StartLine:  98,
+					EndLine:    156,
+					ExitReason: match.ExitAborted,
+					StartedAt:  event.NewGameTime(0, 0),
+					EndedAt:    event.NewGameTime(1, 47),
+					Duration:   event.NewGameTime(1, 47),
 					TotalKills: 4,
-					Players:    []string{"Dono da Bola", "Mocinha", "Isgalamido", "Zeh"},
+					Players:    []string{"Dono da Bola", "Isgalamido", "Zeh"},
 					Kills: map[string]int{
 						"Dono da Bola": -1,
-						"Mocinha":      0,
 						"Isgalamido":   1,
 						"Zeh":          -2,
 					},
@@ -95,10 +126,31 @@ func Test_parseLog(t *testing.T) {
 					},
 					PlayersInGame: map[string]bool{
 						"Dono da Bola": true,
-						"Mocinha":      true,
 						"Isgalamido":   true,
 						"Zeh":          true,
 					},
+					Stats: map[string]*match.PlayerStats{
+						"Dono da Bola": {
+							Deaths: 2, WorldDeaths: 1, Score: -1, KDRatio: 0,
+							KillsByMeans:  map[string]int{},
+							DeathsByMeans: map[string]int{"MOD_ROCKET": 1, "MOD_FALLING": 1},
+						},
+						"Isgalamido": {
+							Frags: 1, Score: 1, KDRatio: 1,
+							KillsByMeans:  map[string]int{"MOD_ROCKET": 1},
+							DeathsByMeans: map[string]int{},
+						},
+						"Zeh": {
+							Deaths: 2, WorldDeaths: 2, Score: -2, KDRatio: 0,
+							KillsByMeans:  map[string]int{},
+							DeathsByMeans: map[string]int{"MOD_TRIGGER_HURT": 2},
+						},
+					},
+					Roster: []*match.Player{
+						{ID: 2, Name: "Dono da Bola", Aliases: []string{"Dono da Bola", "Mocinha", "Dono da Bola"}},
+						{ID: 3, Name: "Isgalamido", Aliases: []string{"Isgalamido"}},
+						{ID: 4, Name: "Zeh", Aliases: []string{"Zeh"}},
+					},
 					Done:       true,
 					InProgress: false,
 				},
@@ -112,6 +164,18 @@ func Test_parseLog(t *testing.T) {
 			},
 			wantMatches: []*match.Match{
 				{
+					ExitReason: match.ExitFragLimit,
+					StartedAt:  event.NewGameTime(6, 34),
+					EndedAt:    event.NewGameTime(14, 11),
+					Duration:   event.NewGameTime(7, 37),
+					Scoreboard: []match.ScoreEntry{
+						{Player: "Oootsimo", Score: 20, Ping: 8, ClientID: 3},
+						{Player: "Zeh", Score: 19, Ping: 14, ClientID: 6},
+						{Player: "Isgalamido", Score: 17, Ping: 1, ClientID: 2},
+						{Player: "Assasinu Credi", Score: 13, Ping: 0, ClientID: 5},
+						{Player: "Dono da Bola", Score: 10, Ping: 8, ClientID: 4},
+						{Player: "Mal", Score: 6, Ping: 19, ClientID: 7},
+					},
 					TotalKills: 131,
 					Players: []string{
 						"Isgalamido",
@@ -160,14 +224,34 @@ func Test_parseLog(t *testing.T) {
 				return
 			}
 
+			assert.Equal(t, len(tt.wantMatches), len(got))
+
 			for i, wantMatch := range tt.wantMatches {
 				gotMatch := got[i]
 
+				if wantMatch.Index > 0 {
+					assert.Equal(t, wantMatch.Index, gotMatch.Index)
+					assert.Equal(t, wantMatch.StartLine, gotMatch.StartLine)
+					assert.Equal(t, wantMatch.EndLine, gotMatch.EndLine)
+				}
 				assert.Equal(t, wantMatch.TotalKills, gotMatch.TotalKills)
 				assert.Equal(t, len(wantMatch.Players), len(gotMatch.Players))
 				assert.Equal(t, wantMatch.InProgress, gotMatch.InProgress)
 				assert.Equal(t, wantMatch.Done, gotMatch.Done)
 
+				if wantMatch.ExitReason != "" {
+					assert.Equal(t, wantMatch.ExitReason, gotMatch.ExitReason)
+					assert.Equal(t, wantMatch.StartedAt, gotMatch.StartedAt)
+					assert.Equal(t, wantMatch.EndedAt, gotMatch.EndedAt)
+					assert.Equal(t, wantMatch.Duration, gotMatch.Duration)
+					assert.Len(t, gotMatch.KillFeed, wantMatch.TotalKills)
+				}
+
+				if wantMatch.Scoreboard != nil {
+					assert.Equal(t, wantMatch.Scoreboard, gotMatch.Scoreboard)
+					assert.Empty(t, gotMatch.Discrepancies())
+				}
+
 				for k, v := range wantMatch.Kills {
 					assert.Equal(t, v, gotMatch.Kills[k])
 				}
@@ -179,7 +263,222 @@ func Test_parseLog(t *testing.T) {
 				for k, v := range wantMatch.PlayersInGame {
 					assert.Equal(t, v, gotMatch.PlayersInGame[k])
 				}
+
+				for k, v := range wantMatch.Stats {
+					assert.Equal(t, v, gotMatch.Stats[k])
+				}
+
+				if wantMatch.Roster != nil {
+					assert.Equal(t, len(wantMatch.Roster), len(gotMatch.Roster))
+					for i, wantPlayer := range wantMatch.Roster {
+						assert.Equal(t, wantPlayer.ID, gotMatch.Roster[i].ID)
+						assert.Equal(t, wantPlayer.Name, gotMatch.Roster[i].Name)
+						assert.Equal(t, wantPlayer.Aliases, gotMatch.Roster[i].Aliases)
+						assert.Equal(t, wantPlayer.Reconnects, gotMatch.Roster[i].Reconnects)
+						assert.Equal(t, wantPlayer.LeftEarly, gotMatch.Roster[i].LeftEarly)
+					}
+				}
 			}
 		})
 	}
 }
+
+func TestParseReader(t *testing.T) {
+	logContent, err := os.ReadFile("./testfiles/qgames_complete_match.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should parse the matches from an in-memory reader", func(t *testing.T) {
+		got, err := ParseReader(context.Background(), bytes.NewReader(logContent))
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, 131, got[0].TotalKills)
+		assert.Equal(t, 6, len(got[0].Players))
+	})
+
+	t.Run("should stop parsing and return the context error when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, err := ParseReader(ctx, bytes.NewReader(logContent))
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, got)
+	})
+
+	t.Run("should close a reader blocked in Read when the context is cancelled", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			_, _ = pw.Write([]byte("  0:00 InitGame: \\mapname\\q3dm17\n"))
+			cancel()
+		}()
+
+		got, err := ParseReader(ctx, pr)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, got)
+
+		assert.Eventually(t, func() bool {
+			_, err := pw.Write([]byte("\n"))
+			return errors.Is(err, io.ErrClosedPipe)
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should return an error when a log line exceeds the max line size", func(t *testing.T) {
+		got, err := ParseReader(context.Background(), bytes.NewReader(logContent), WithMaxLineSize(64))
+		assert.ErrorIs(t, err, bufio.ErrTooLong)
+		assert.Nil(t, got)
+	})
+}
+
+func TestParseReader_MatchObserver(t *testing.T) {
+	t.Run("should observe every match with its events in log order", func(t *testing.T) {
+		file, err := os.Open("./testfiles/qgames_three_matches.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		var indexes []int
+		kills := 0
+		observer := func(gameMatch *match.Match, events []event.Event) error {
+			indexes = append(indexes, gameMatch.Index)
+
+			assert.IsType(t, &event.InitGameEvent{}, events[0])
+			assert.True(t, event.EndsMatch(events[len(events)-1]))
+			for i, e := range events {
+				if i > 0 {
+					assert.Greater(t, e.LineNumber(), events[i-1].LineNumber())
+				}
+
+				if e.Type() == event.TypeKill {
+					kills++
+				}
+			}
+
+			return nil
+		}
+
+		got, err := ParseReader(context.Background(), file, WithMatchObserver(observer))
+		assert.NoError(t, err)
+		assert.Len(t, got, 3)
+		assert.Equal(t, []int{1, 2, 3}, indexes)
+		assert.Equal(t, 15, kills)
+	})
+
+	t.Run("should stop on an observer error", func(t *testing.T) {
+		file, err := os.Open("./testfiles/qgames_three_matches.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		observed := 0
+		observer := func(*match.Match, []event.Event) error {
+			observed++
+			return errors.New("observer failed")
+		}
+
+		got, err := ParseReader(context.Background(), file, WithMatchObserver(observer))
+		assert.Nil(t, got)
+		assert.EqualError(t, err, "observer failed")
+		assert.Equal(t, 1, observed)
+	})
+}
+
+func TestParseReader_Chat(t *testing.T) {
+	logContent := strings.Join([]string{
+		"  0:00 InitGame: \\sv_floodProtect\\1\\g_gametype\\4\\mapname\\Q3TOURNEY6_CTF",
+		"  0:01 ClientConnect: 2",
+		"  0:01 ClientUserinfoChanged: 2 n\\Oootsimo\\t\\1\\model\\razor/id",
+		"  0:01 ClientBegin: 2",
+		"  0:21 say: Oootsimo: team red",
+		"  0:26 sayteam: Oootsimo: go go",
+		"  1:00 ShutdownGame:",
+	}, "\n")
+
+	t.Run("should record the chat of the match", func(t *testing.T) {
+		got, err := ParseReader(context.Background(), strings.NewReader(logContent))
+		assert.NoError(t, err)
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, []match.ChatMessage{
+				{At: event.NewGameTime(0, 21), ClientID: 2, Player: "Oootsimo", Channel: match.ChatAll, Text: "team red"},
+				{At: event.NewGameTime(0, 26), ClientID: 2, Player: "Oootsimo", Channel: match.ChatTeam, Text: "go go"},
+			}, got[0].Chat)
+		}
+	})
+
+	t.Run("should leave the chat out of the match when excluding chat", func(t *testing.T) {
+		got, err := ParseReader(context.Background(), strings.NewReader(logContent), WithoutChat())
+		assert.NoError(t, err)
+		if assert.Len(t, got, 1) {
+			assert.Empty(t, got[0].Chat)
+			assert.Equal(t, []string{"Oootsimo"}, got[0].Players)
+		}
+	})
+}
+
+type failingKillHandler struct{}
+
+func (h *failingKillHandler) Handle(e event.Event, match *match.Match) error {
+	if _, ok := e.(*event.KillEvent); ok {
+		return NewHandlerError("failingKillHandler", errors.New("unexpected kill"))
+	}
+
+	return LoadLogsDigester().Handle(e, match)
+}
+
+func TestParseReader_Errors(t *testing.T) {
+	withFailingDigester := func(c *config) {
+		c.digester = &failingKillHandler{}
+	}
+
+	t.Run("should abort on the first error in log order when in strict mode", func(t *testing.T) {
+		file, err := os.Open("./testfiles/qgames_three_matches.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		got, err := ParseReader(context.Background(), file, WithSourceName("three_matches.log"), withFailingDigester)
+		assert.Nil(t, got)
+
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr) {
+			assert.Equal(t, "three_matches.log", parseErr.File)
+			assert.Equal(t, 18, parseErr.Line)
+			assert.Equal(t, " 20:54 Kill: 1022 2 22: <world> killed Isgalamido by MOD_TRIGGER_HURT", parseErr.Raw)
+			assert.Equal(t, "failingKillHandler", parseErr.Handler)
+			assert.EqualError(t, parseErr.Err, "unexpected kill")
+		}
+	})
+
+	t.Run("should collect every error and keep parsing when in lenient mode", func(t *testing.T) {
+		file, err := os.Open("./testfiles/qgames_three_matches.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		got, err := ParseReader(context.Background(), file, WithErrorMode(Lenient), withFailingDigester)
+		assert.Len(t, got, 3)
+
+		var parseErrs ParseErrors
+		if assert.ErrorAs(t, err, &parseErrs) {
+			assert.Len(t, parseErrs, 15)
+			assert.Equal(t, 18, parseErrs[0].Line)
+			assert.Equal(t, 149, parseErrs[len(parseErrs)-1].Line)
+		}
+	})
+
+	t.Run("should return a parse error with the file name when the log file cannot be scanned", func(t *testing.T) {
+		got, err := ParseLog("./testfiles/qgames_complete_match.log", WithMaxLineSize(64))
+		assert.Nil(t, got)
+
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr) {
+			assert.Equal(t, "./testfiles/qgames_complete_match.log", parseErr.File)
+			assert.Equal(t, 1, parseErr.Line)
+		}
+	})
+}
@@ -0,0 +1,293 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"log-parser/event"
+	"log-parser/match"
+	"slices"
+)
+
+const (
+	HandlerInitGame    = "init_game"
+	HandlerAddPlayer   = "add_player"
+	HandlerSession     = "session"
+	HandlerFlag        = "flag"
+	HandlerItem        = "item"
+	HandlerKillDetails = "kill_details"
+	HandlerChat        = "chat"
+	HandlerExit        = "exit"
+	HandlerTeamScore   = "team_score"
+	HandlerScore       = "score"
+	HandlerEndGame     = "end_game"
+)
+
+var (
+	ErrHandlerRegistered    = errors.New("handler already registered")
+	ErrHandlerNotRegistered = errors.New("handler not registered")
+	ErrHandlerSelfMove      = errors.New("handler moved relative to itself")
+
+	// errPassedOn is what a chained handler gets back from its next handler
+	// when it passes an event on, telling the chain to go on to the handler
+	// registered after it.
+	errPassedOn = errors.New("event passed on")
+)
+
+type (
+	// EventFilter is implemented by handlers that only handle some types of
+	// event. When every registered handler is one, the parser leaves out the
+	// log lines none of them handles.
+	EventFilter interface {
+		EventTypes() []string
+	}
+
+	// Registry holds named digester handlers in the order they see the events.
+	// A handler with a SetNext method is chained as the built-in ones are, and
+	// decides itself which events go on to the next handler. Any other handler
+	// sees every event that reaches it and then passes it on.
+	//
+	// The matches of a log are digested concurrently by the same handler
+	// instances, so handlers must be safe for concurrent use. Registering a
+	// chained handler sets its next handler once; building a digester leaves
+	// the handlers untouched.
+	Registry struct {
+		entries []registryEntry
+	}
+
+	registryEntry struct {
+		name    string
+		handler LogDigesterHandler
+	}
+
+	chainedHandler interface {
+		LogDigesterHandler
+		SetNext(handler LogDigesterHandler)
+	}
+
+	// registeredHandler runs a handler of the chain, naming the errors it
+	// returns after the name it was registered under.
+	registeredHandler struct {
+		name    string
+		handler LogDigesterHandler
+		next    LogDigesterHandler
+	}
+
+	discardHandler struct{}
+
+	passOnHandler struct{}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry holds the built-in handlers in the order LoadLogsDigester
+// chains them.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, entry := range []registryEntry{
+		{name: HandlerInitGame, handler: NewInitGameHandler()},
+		{name: HandlerAddPlayer, handler: NewAddPlayerHandler()},
+		{name: HandlerSession, handler: NewSessionHandler()},
+		{name: HandlerFlag, handler: NewFlagHandler()},
+		{name: HandlerItem, handler: NewItemHandler()},
+		{name: HandlerKillDetails, handler: NewKillDetailsHandler()},
+		{name: HandlerChat, handler: NewChatHandler()},
+		{name: HandlerExit, handler: NewExitHandler()},
+		{name: HandlerTeamScore, handler: NewTeamScoreHandler()},
+		{name: HandlerScore, handler: NewScoreHandler()},
+		{name: HandlerEndGame, handler: NewEndGameHandler()},
+	} {
+		// the names are distinct and the handlers not nil, so it cannot fail.
+		_ = r.Register(entry.name, entry.handler)
+	}
+
+	return r
+}
+
+// WithRegistry digests the matches with the handlers of r, as registered when
+// the option is applied.
+func WithRegistry(r *Registry) Option {
+	return func(c *config) {
+		c.digester = r.Digester()
+		c.eventTypes = r.eventTypes()
+	}
+}
+
+// Register adds handler at the end of the chain.
+func (r *Registry) Register(name string, handler LogDigesterHandler) error {
+	return r.insert(len(r.entries), name, handler)
+}
+
+// RegisterBefore adds handler right before the handler registered as before.
+func (r *Registry) RegisterBefore(before, name string, handler LogDigesterHandler) error {
+	i, err := r.index(before)
+	if err != nil {
+		return err
+	}
+
+	return r.insert(i, name, handler)
+}
+
+// RegisterAfter adds handler right after the handler registered as after.
+func (r *Registry) RegisterAfter(after, name string, handler LogDigesterHandler) error {
+	i, err := r.index(after)
+	if err != nil {
+		return err
+	}
+
+	return r.insert(i+1, name, handler)
+}
+
+func (r *Registry) Remove(name string) error {
+	i, err := r.index(name)
+	if err != nil {
+		return err
+	}
+
+	r.entries = slices.Delete(r.entries, i, i+1)
+
+	return nil
+}
+
+// MoveBefore moves the handler registered as name right before the handler
+// registered as before.
+func (r *Registry) MoveBefore(name, before string) error {
+	return r.move(name, before, 0)
+}
+
+// MoveAfter moves the handler registered as name right after the handler
+// registered as after.
+func (r *Registry) MoveAfter(name, after string) error {
+	return r.move(name, after, 1)
+}
+
+// Names lists the registered handlers in chain order.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.entries))
+	for i, entry := range r.entries {
+		names[i] = entry.name
+	}
+
+	return names
+}
+
+// Digester chains the registered handlers in new nodes, so digesters built
+// from the same registry can be used at the same time.
+func (r *Registry) Digester() LogDigesterHandler {
+	var next LogDigesterHandler
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		entry := r.entries[i]
+		next = &registeredHandler{name: entry.name, handler: entry.handler, next: next}
+	}
+
+	if next == nil {
+		return discardHandler{}
+	}
+
+	return next
+}
+
+// eventTypes is the set of event types the registered handlers handle, or nil
+// when a handler does not tell which ones it does.
+func (r *Registry) eventTypes() map[string]bool {
+	types := make(map[string]bool)
+	for _, entry := range r.entries {
+		filter, ok := entry.handler.(EventFilter)
+		if !ok {
+			return nil
+		}
+
+		for _, eventType := range filter.EventTypes() {
+			types[eventType] = true
+		}
+	}
+
+	return types
+}
+
+func (r *Registry) index(name string) (int, error) {
+	i := slices.IndexFunc(r.entries, func(entry registryEntry) bool {
+		return entry.name == name
+	})
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrHandlerNotRegistered, name)
+	}
+
+	return i, nil
+}
+
+func (r *Registry) insert(i int, name string, handler LogDigesterHandler) error {
+	if handler == nil {
+		return fmt.Errorf("registering %q: nil handler", name)
+	}
+
+	if _, err := r.index(name); err == nil {
+		return fmt.Errorf("%w: %q", ErrHandlerRegistered, name)
+	}
+
+	if chained, ok := handler.(chainedHandler); ok {
+		chained.SetNext(passOnHandler{})
+	}
+
+	r.entries = slices.Insert(r.entries, i, registryEntry{name: name, handler: handler})
+
+	return nil
+}
+
+func (r *Registry) move(name, target string, offset int) error {
+	i, err := r.index(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.index(target); err != nil {
+		return err
+	}
+
+	if name == target {
+		return fmt.Errorf("%w: %q", ErrHandlerSelfMove, name)
+	}
+
+	entry := r.entries[i]
+	r.entries = slices.Delete(r.entries, i, i+1)
+
+	j, err := r.index(target)
+	if err != nil {
+		return err
+	}
+	r.entries = slices.Insert(r.entries, j+offset, entry)
+
+	return nil
+}
+
+func (h *registeredHandler) Handle(e event.Event, gameMatch *match.Match) error {
+	err := h.handler.Handle(e, gameMatch)
+	_, chained := h.handler.(chainedHandler)
+	if chained && err == nil {
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, errPassedOn) {
+		var handlerErr *HandlerError
+		if errors.As(err, &handlerErr) {
+			return err
+		}
+
+		return NewHandlerError(h.name, err)
+	}
+
+	if h.next != nil {
+		return h.next.Handle(e, gameMatch)
+	}
+
+	return nil
+}
+
+func (discardHandler) Handle(event.Event, *match.Match) error {
+	return nil
+}
+
+func (passOnHandler) Handle(event.Event, *match.Match) error {
+	return errPassedOn
+}
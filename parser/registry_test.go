@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"log-parser/event"
+	"log-parser/match"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingHandler struct {
+	mu     sync.Mutex
+	types  []string
+	counts map[string]int
+}
+
+func (h *countingHandler) Handle(e event.Event, _ *match.Match) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[e.Type()]++
+
+	return nil
+}
+
+type filteringCountingHandler struct {
+	countingHandler
+}
+
+func (h *filteringCountingHandler) EventTypes() []string {
+	return h.types
+}
+
+type failingHandler struct{}
+
+func (h *failingHandler) Handle(e event.Event, _ *match.Match) error {
+	if e.Type() == event.TypeKill {
+		return errors.New("unexpected kill")
+	}
+
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("should register handlers in the given places", func(t *testing.T) {
+		r := NewRegistry()
+		assert.NoError(t, r.Register("a", NewInitGameHandler()))
+		assert.NoError(t, r.Register("d", NewEndGameHandler()))
+		assert.NoError(t, r.RegisterBefore("d", "c", NewKillDetailsHandler()))
+		assert.NoError(t, r.RegisterAfter("a", "b", NewAddPlayerHandler()))
+
+		assert.Equal(t, []string{"a", "b", "c", "d"}, r.Names())
+	})
+
+	t.Run("should not register a name twice", func(t *testing.T) {
+		r := NewRegistry()
+		assert.NoError(t, r.Register("a", NewInitGameHandler()))
+		assert.ErrorIs(t, r.Register("a", NewEndGameHandler()), ErrHandlerRegistered)
+	})
+
+	t.Run("should not register a nil handler", func(t *testing.T) {
+		assert.Error(t, NewRegistry().Register("a", nil))
+	})
+
+	t.Run("should remove and move handlers", func(t *testing.T) {
+		r := DefaultRegistry()
+		assert.NoError(t, r.Remove(HandlerChat))
+		assert.NoError(t, r.MoveBefore(HandlerEndGame, HandlerInitGame))
+		assert.NoError(t, r.MoveAfter(HandlerInitGame, HandlerScore))
+
+		assert.Equal(t, []string{
+			HandlerEndGame,
+			HandlerAddPlayer,
+			HandlerSession,
+			HandlerFlag,
+			HandlerItem,
+			HandlerKillDetails,
+			HandlerExit,
+			HandlerTeamScore,
+			HandlerScore,
+			HandlerInitGame,
+		}, r.Names())
+	})
+
+	t.Run("should fail on handlers that are not registered", func(t *testing.T) {
+		r := DefaultRegistry()
+		assert.ErrorIs(t, r.Remove("missing"), ErrHandlerNotRegistered)
+		assert.ErrorIs(t, r.RegisterBefore("missing", "a", NewInitGameHandler()), ErrHandlerNotRegistered)
+		assert.ErrorIs(t, r.MoveAfter(HandlerChat, "missing"), ErrHandlerNotRegistered)
+		assert.Equal(t, DefaultRegistry().Names(), r.Names())
+	})
+
+	t.Run("should not move a handler relative to itself", func(t *testing.T) {
+		r := DefaultRegistry()
+		assert.ErrorIs(t, r.MoveBefore(HandlerEndGame, HandlerEndGame), ErrHandlerSelfMove)
+		assert.ErrorIs(t, r.MoveAfter(HandlerChat, HandlerChat), ErrHandlerSelfMove)
+		assert.Equal(t, DefaultRegistry().Names(), r.Names())
+	})
+
+	t.Run("should derive the gathered events from the handlers", func(t *testing.T) {
+		r := NewRegistry()
+		assert.NoError(t, r.Register("kills", &filteringCountingHandler{countingHandler{types: []string{event.TypeKill}}}))
+		assert.NoError(t, r.Register(HandlerEndGame, NewEndGameHandler()))
+		assert.Equal(t, map[string]bool{event.TypeKill: true, event.TypeShutdownGame: true, event.TypeRestart: true}, r.eventTypes())
+
+		assert.NoError(t, r.Register("all", &countingHandler{}))
+		assert.Nil(t, r.eventTypes())
+	})
+}
+
+func TestParseReader_Registry(t *testing.T) {
+	parse := func(t *testing.T, r *Registry) ([]*match.Match, error) {
+		file, err := os.Open("./testfiles/qgames_three_matches.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		return ParseReader(context.Background(), file, WithRegistry(r))
+	}
+
+	t.Run("should pass every event on through a handler without SetNext", func(t *testing.T) {
+		counter := &countingHandler{counts: make(map[string]int)}
+
+		r := DefaultRegistry()
+		assert.NoError(t, r.RegisterBefore(HandlerInitGame, "counter", counter))
+
+		got, err := parse(t, r)
+		assert.NoError(t, err)
+		assert.Len(t, got, 3)
+		assert.Equal(t, 15, counter.counts[event.TypeKill])
+		assert.Equal(t, 3, counter.counts[event.TypeInitGame])
+		assert.Equal(t, 15, got[0].TotalKills+got[1].TotalKills+got[2].TotalKills)
+	})
+
+	t.Run("should only gather the events the handlers handle", func(t *testing.T) {
+		counter := &filteringCountingHandler{countingHandler{
+			types:  []string{event.TypeKill},
+			counts: make(map[string]int),
+		}}
+
+		r := DefaultRegistry()
+		for _, name := range r.Names() {
+			if name != HandlerInitGame && name != HandlerEndGame {
+				assert.NoError(t, r.Remove(name))
+			}
+		}
+		assert.NoError(t, r.RegisterAfter(HandlerInitGame, "counter", counter))
+
+		got, err := parse(t, r)
+		assert.NoError(t, err)
+		assert.Len(t, got, 3)
+		assert.Equal(t, map[string]int{event.TypeKill: 15, event.TypeShutdownGame: 2, event.TypeRestart: 1}, counter.counts)
+		assert.Zero(t, got[0].TotalKills)
+	})
+
+	t.Run("should parse concurrently with one registry", func(t *testing.T) {
+		r := DefaultRegistry()
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				got, err := parse(t, r)
+				assert.NoError(t, err)
+				assert.Len(t, got, 3)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("should name the errors after the registered handler", func(t *testing.T) {
+		r := DefaultRegistry()
+		assert.NoError(t, r.RegisterBefore(HandlerKillDetails, "no_kills", &failingHandler{}))
+
+		got, err := parse(t, r)
+		assert.Nil(t, got)
+
+		var parseErr *ParseError
+		if assert.ErrorAs(t, err, &parseErr) {
+			assert.Equal(t, "no_kills", parseErr.Handler)
+			assert.EqualError(t, parseErr.Err, "unexpected kill")
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"log-parser/event"
+	"log-parser/match"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxBarWidth = 200
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).ParseFS(templateFS, "templates/*.html"))
+
+type (
+	indexPage struct {
+		Metadata        Metadata
+		MultipleSources bool
+		Matches         []matchLink
+	}
+
+	matchLink struct {
+		Page   string
+		Winner string
+		Report MatchReport
+	}
+
+	matchPage struct {
+		Metadata   Metadata
+		Report     MatchReport
+		TeamGame   bool
+		Scoreboard []scoreRow
+		Means      []meansRow
+		Matrix     []matrixRow
+	}
+
+	scoreRow struct {
+		Player      string
+		Team        match.Team
+		Score       int
+		Reported    string
+		Discrepancy bool
+		Frags       int
+		Deaths      int
+		Suicides    int
+		WorldDeaths int
+		KDRatio     float64
+		TimePlayed  event.GameTime
+	}
+
+	meansRow struct {
+		Means   string
+		Kills   int
+		Percent int
+		Width   int
+	}
+
+	matrixRow struct {
+		Killer string
+		Cells  []matrixCell
+	}
+
+	matrixCell struct {
+		Kills int
+		Self  bool
+		Top   bool
+	}
+)
+
+// WriteHTML writes the report as a set of self-contained HTML pages into dir,
+// creating it when needed: index.html lists the matches and links to a page
+// per match with its scoreboard, kills by means, kill matrix and rivalries.
+func (r *Report) WriteHTML(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating the html report directory: %w", err)
+	}
+
+	index := indexPage{
+		Metadata:        r.Metadata,
+		MultipleSources: len(r.Metadata.Sources) > 1,
+		Matches:         make([]matchLink, 0, len(r.Matches)),
+	}
+
+	for _, matchReport := range r.Matches {
+		link := matchLink{
+			Page:   r.pageName(matchReport),
+			Winner: winner(matchReport.Match),
+			Report: matchReport,
+		}
+		index.Matches = append(index.Matches, link)
+
+		page := matchPage{
+			Metadata:   r.Metadata,
+			Report:     matchReport,
+			TeamGame:   matchReport.Match.Settings.GameType.IsTeamGame(),
+			Scoreboard: scoreboard(matchReport.Match),
+			Means:      kindsOfKills(matchReport.Match, matchReport.Summary.KillsByMeans),
+			Matrix:     killMatrix(matchReport.Match),
+		}
+
+		if err := writePage(filepath.Join(dir, link.Page), "match.html", page); err != nil {
+			return err
+		}
+	}
+
+	return writePage(filepath.Join(dir, "index.html"), "index.html", index)
+}
+
+// pageName names the page of a match after its game, and after its log too
+// when the report holds more than one.
+func (r *Report) pageName(matchReport MatchReport) string {
+	if len(r.Metadata.Sources) <= 1 {
+		return matchReport.Game + ".html"
+	}
+
+	source := slices.Index(r.Metadata.Sources, matchReport.Source) + 1
+
+	return fmt.Sprintf("log_%d_%s.html", source, matchReport.Game)
+}
+
+func writePage(path, name string, data any) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Base(path), err)
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf("closing %s: %w", filepath.Base(path), closeErr)
+		}
+	}()
+
+	if err := templates.ExecuteTemplate(file, name, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// scoreboard ranks the players by computed score, then frags, keeping the
+// order they joined in on ties. Reported scores are flagged when they are
+// among the discrepancies of the match.
+func scoreboard(gameMatch *match.Match) []scoreRow {
+	discrepancies := gameMatch.Discrepancies()
+
+	rows := make([]scoreRow, 0, len(gameMatch.Players))
+	for _, name := range gameMatch.Players {
+		row := scoreRow{Player: name, Reported: "-"}
+
+		if stats, ok := gameMatch.Stats[name]; ok {
+			row.Score = stats.Score
+			row.Frags = stats.Frags
+			row.Deaths = stats.Deaths
+			row.Suicides = stats.Suicides
+			row.WorldDeaths = stats.WorldDeaths
+			row.KDRatio = stats.KDRatio
+		}
+
+		for _, player := range gameMatch.Roster {
+			if player.Name == name {
+				row.Team = player.Team
+				row.TimePlayed = player.TimePlayed
+				break
+			}
+		}
+
+		for _, entry := range gameMatch.Scoreboard {
+			if entry.Player == name {
+				row.Reported = strconv.Itoa(entry.Score)
+				break
+			}
+		}
+
+		row.Discrepancy = slices.ContainsFunc(discrepancies, func(discrepancy match.Discrepancy) bool {
+			return discrepancy.Player == name
+		})
+
+		rows = append(rows, row)
+	}
+
+	slices.SortStableFunc(rows, func(a, b scoreRow) int {
+		if a.Score != b.Score {
+			return b.Score - a.Score
+		}
+
+		return b.Frags - a.Frags
+	})
+
+	return rows
+}
+
+func kindsOfKills(gameMatch *match.Match, killsByMeans map[string]int) []meansRow {
+	rows := make([]meansRow, 0, len(killsByMeans))
+	for means, kills := range killsByMeans {
+		row := meansRow{Means: means, Kills: kills}
+		if gameMatch.TotalKills > 0 {
+			row.Percent = kills * 100 / gameMatch.TotalKills
+			row.Width = kills * maxBarWidth / gameMatch.TotalKills
+		}
+
+		rows = append(rows, row)
+	}
+
+	slices.SortFunc(rows, func(a, b meansRow) int {
+		if a.Kills != b.Kills {
+			return b.Kills - a.Kills
+		}
+
+		return strings.Compare(a.Means, b.Means)
+	})
+
+	return rows
+}
+
+// killMatrix lays out who killed whom with the players in the order they
+// joined, marking the most kills of each killer.
+func killMatrix(gameMatch *match.Match) []matrixRow {
+	rows := make([]matrixRow, 0, len(gameMatch.Players))
+	for _, killer := range gameMatch.Players {
+		row := matrixRow{Killer: killer, Cells: make([]matrixCell, 0, len(gameMatch.Players))}
+
+		top := 0
+		for _, kills := range gameMatch.KillMatrix[killer] {
+			top = max(top, kills)
+		}
+
+		for _, victim := range gameMatch.Players {
+			kills := gameMatch.KillMatrix[killer][victim]
+			row.Cells = append(row.Cells, matrixCell{
+				Kills: kills,
+				Self:  killer == victim,
+				Top:   kills > 0 && kills == top,
+			})
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// winner is the team with the higher score in team games, or else the player
+// with the highest score, as reported by the server when the match reached
+// its scoreboard.
+func winner(gameMatch *match.Match) string {
+	if gameMatch.TeamScore != nil {
+		switch {
+		case gameMatch.TeamScore.Red > gameMatch.TeamScore.Blue:
+			return match.TeamRed.String()
+		case gameMatch.TeamScore.Blue > gameMatch.TeamScore.Red:
+			return match.TeamBlue.String()
+		default:
+			return "draw"
+		}
+	}
+
+	if len(gameMatch.Scoreboard) > 0 {
+		best := gameMatch.Scoreboard[0]
+		for _, entry := range gameMatch.Scoreboard[1:] {
+			if entry.Score > best.Score {
+				best = entry
+			}
+		}
+
+		return best.Player
+	}
+
+	rows := scoreboard(gameMatch)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	return rows[0].Player
+}
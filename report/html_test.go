@@ -0,0 +1,116 @@
+package report
+
+import (
+	"log-parser/event"
+	"log-parser/match"
+	"log-parser/parser"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readPage(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	page, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(page)
+}
+
+func TestReport_WriteHTML(t *testing.T) {
+	matches, err := parser.ParseLog("../parser/testfiles/qgames_three_matches.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(time.Date(2024, 3, 9, 21, 0, 0, 0, time.UTC))
+	r.Add("three_matches.log", matches)
+
+	dir := filepath.Join(t.TempDir(), "html")
+	assert.NoError(t, r.WriteHTML(dir))
+
+	index := readPage(t, dir, "index.html")
+	for _, page := range []string{"game_1.html", "game_2.html", "game_3.html"} {
+		assert.Contains(t, index, `<a href="`+page+`">`)
+		assert.FileExists(t, filepath.Join(dir, page))
+	}
+	assert.Contains(t, index, "3 matches from three_matches.log")
+
+	page := readPage(t, dir, "game_2.html")
+	assert.Contains(t, page, "<h1>game_2 &middot; q3dm17</h1>")
+	assert.Contains(t, page, "<h2>Scoreboard</h2>")
+	assert.Contains(t, page, "<td>MOD_TRIGGER_HURT</td>")
+	assert.Contains(t, page, "<h2>Kill matrix</h2>")
+	assert.Contains(t, page, "<h2>Rivalries</h2>")
+	assert.NotContains(t, page, "<link")
+	assert.NotContains(t, page, "<script")
+}
+
+func TestReport_WriteHTML_MultipleSources(t *testing.T) {
+	m := match.NewMatch()
+	m.Index = 1
+
+	r := New(time.Now())
+	r.Add("a.log", []*match.Match{m})
+	r.Add("b.log", []*match.Match{m})
+
+	dir := t.TempDir()
+	assert.NoError(t, r.WriteHTML(dir))
+
+	index := readPage(t, dir, "index.html")
+	assert.Contains(t, index, `<a href="log_1_game_1.html">`)
+	assert.Contains(t, index, `<a href="log_2_game_1.html">`)
+}
+
+func TestReport_WriteHTML_Escaping(t *testing.T) {
+	m := match.NewMatch()
+	m.Index = 1
+	m.SetPlayerName(2, "<b>Zeh</b>")
+	m.AddKill(match.KillEvent{At: event.NewGameTime(1, 0), KillerID: 1022, VictimID: 2, Killer: "<world>", Victim: "<b>Zeh</b>", Means: "MOD_FALLING"})
+
+	r := New(time.Now())
+	r.Add("games.log", []*match.Match{m})
+
+	dir := t.TempDir()
+	assert.NoError(t, r.WriteHTML(dir))
+
+	page := readPage(t, dir, "game_1.html")
+	assert.Contains(t, page, "&lt;b&gt;Zeh&lt;/b&gt;")
+	assert.NotContains(t, page, "<b>Zeh</b>")
+}
+
+func TestScoreboard(t *testing.T) {
+	m := match.NewMatch()
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerName(3, "Dono da Bola")
+	m.SetPlayerName(4, "Zeh")
+	m.AddKill(match.KillEvent{At: event.NewGameTime(1, 0), KillerID: 4, VictimID: 2, Killer: "Zeh", Victim: "Isgalamido", Means: "MOD_ROCKET"})
+	m.AddKill(match.KillEvent{At: event.NewGameTime(1, 5), KillerID: 4, VictimID: 3, Killer: "Zeh", Victim: "Dono da Bola", Means: "MOD_ROCKET"})
+	m.AddKill(match.KillEvent{At: event.NewGameTime(1, 9), KillerID: 3, VictimID: 4, Killer: "Dono da Bola", Victim: "Zeh", Means: "MOD_SHOTGUN"})
+	m.AddScore(match.ScoreEntry{Player: "Zeh", Score: 3, ClientID: 4})
+
+	rows := scoreboard(m)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "Zeh", rows[0].Player)
+		assert.Equal(t, "3", rows[0].Reported)
+		assert.True(t, rows[0].Discrepancy)
+		assert.Equal(t, "Dono da Bola", rows[1].Player)
+		assert.Equal(t, "-", rows[1].Reported)
+		assert.Equal(t, "Isgalamido", rows[2].Player)
+	}
+
+	assert.Equal(t, "Zeh", winner(m))
+
+	matrix := killMatrix(m)
+	if assert.Len(t, matrix, 3) {
+		zeh := matrix[2]
+		assert.Equal(t, "Zeh", zeh.Killer)
+		assert.Equal(t, []matrixCell{{Kills: 1, Top: true}, {Kills: 1, Top: true}, {Self: true}}, zeh.Cells)
+	}
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log-parser/match"
+	"runtime/debug"
+	"time"
+)
+
+// Version is the version of the report document. It changes whenever a field
+// is renamed or removed, so readers can tell which documents they understand.
+const Version = 1
+
+// ParserVersion is the version of the parser written to the reports. It can be
+// set at build time with -ldflags "-X log-parser/report.ParserVersion=v1.2.0",
+// and otherwise falls back to the module version of the build.
+var ParserVersion string
+
+type (
+	// Report is the whole output of a parse run as a single document, which
+	// can be written with Write and read back with Decode.
+	Report struct {
+		Version  int           `json:"version"`
+		Metadata Metadata      `json:"metadata"`
+		Matches  []MatchReport `json:"matches"`
+	}
+
+	Metadata struct {
+		Sources       []string  `json:"sources"`
+		GeneratedAt   time.Time `json:"generated_at"`
+		ParserVersion string    `json:"parser_version"`
+		MatchCount    int       `json:"match_count"`
+	}
+
+	// MatchReport is a parsed match together with the summaries worked out
+	// from it. Teams only applies to team games and CTF to CTF matches.
+	MatchReport struct {
+		Game          string              `json:"game"`
+		Source        string              `json:"source"`
+		Index         int                 `json:"index"`
+		Match         *match.Match        `json:"match"`
+		Summary       match.Summary       `json:"summary"`
+		Teams         []match.TeamSummary `json:"teams,omitempty"`
+		ItemControl   []match.ItemControl `json:"item_control"`
+		Rivalries     []match.Rivalry     `json:"rivalries"`
+		CTF           *match.CTFSummary   `json:"ctf,omitempty"`
+		Discrepancies []match.Discrepancy `json:"discrepancies"`
+	}
+)
+
+func New(generatedAt time.Time) *Report {
+	return &Report{
+		Version: Version,
+		Metadata: Metadata{
+			Sources:       make([]string, 0),
+			GeneratedAt:   generatedAt,
+			ParserVersion: parserVersion(),
+		},
+		Matches: make([]MatchReport, 0),
+	}
+}
+
+// Add reports the matches parsed from the log at source.
+func (r *Report) Add(source string, matches []*match.Match) {
+	r.Metadata.Sources = append(r.Metadata.Sources, source)
+
+	for _, gameMatch := range matches {
+		matchReport := MatchReport{
+			Game:          fmt.Sprintf("game_%d", gameMatch.Index),
+			Source:        source,
+			Index:         gameMatch.Index,
+			Match:         gameMatch,
+			Summary:       gameMatch.Summary(),
+			ItemControl:   gameMatch.ItemControl(),
+			Rivalries:     gameMatch.Rivalries(),
+			Discrepancies: gameMatch.Discrepancies(),
+		}
+
+		if gameMatch.Settings.GameType.IsTeamGame() {
+			matchReport.Teams = gameMatch.Teams()
+		}
+
+		if gameMatch.Settings.GameType == match.GameTypeCaptureTheFlag {
+			ctf := gameMatch.CTF()
+			matchReport.CTF = &ctf
+		}
+
+		r.Matches = append(r.Matches, matchReport)
+	}
+
+	r.Metadata.MatchCount = len(r.Matches)
+}
+
+func (r *Report) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("encoding the report: %w", err)
+	}
+
+	return nil
+}
+
+// Decode reads a report written by Write. It fails on documents of a newer
+// version than this package knows of.
+func Decode(r io.Reader) (*Report, error) {
+	var report Report
+	if err := json.NewDecoder(r).Decode(&report); err != nil {
+		return nil, fmt.Errorf("decoding the report: %w", err)
+	}
+
+	if report.Version < 1 || report.Version > Version {
+		return nil, fmt.Errorf("unsupported report version %d", report.Version)
+	}
+
+	// the match leaves out what the report holds elsewhere.
+	for _, matchReport := range report.Matches {
+		if matchReport.Match == nil {
+			continue
+		}
+
+		matchReport.Match.Index = matchReport.Index
+		matchReport.Match.KillsByMeans = matchReport.Summary.KillsByMeans
+		matchReport.Match.Done = true
+	}
+
+	return &report, nil
+}
+
+func parserVersion() string {
+	if ParserVersion != "" {
+		return ParserVersion
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return "dev"
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"log-parser/match"
+	"log-parser/parser"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Add(t *testing.T) {
+	matches, err := parser.ParseLog("../parser/testfiles/qgames_three_matches.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generatedAt := time.Date(2024, 3, 9, 21, 0, 0, 0, time.UTC)
+
+	r := New(generatedAt)
+	r.Add("three_matches.log", matches)
+
+	assert.Equal(t, Version, r.Version)
+	assert.Equal(t, []string{"three_matches.log"}, r.Metadata.Sources)
+	assert.Equal(t, generatedAt, r.Metadata.GeneratedAt)
+	assert.NotEmpty(t, r.Metadata.ParserVersion)
+	assert.Equal(t, 3, r.Metadata.MatchCount)
+
+	if assert.Len(t, r.Matches, 3) {
+		second := r.Matches[1]
+		assert.Equal(t, "game_2", second.Game)
+		assert.Equal(t, "three_matches.log", second.Source)
+		assert.Equal(t, 2, second.Index)
+		assert.Same(t, matches[1], second.Match)
+		assert.Equal(t, matches[1].KillsByMeans, second.Summary.KillsByMeans)
+		assert.Nil(t, second.Teams)
+		assert.Nil(t, second.CTF)
+		assert.Equal(t, matches[1].Rivalries(), second.Rivalries)
+		assert.NotNil(t, second.Discrepancies)
+	}
+}
+
+func TestReport_Add_TeamGame(t *testing.T) {
+	m := match.NewMatch()
+	m.Index = 1
+	m.Settings.GameType = match.GameTypeCaptureTheFlag
+	m.SetPlayerName(2, "Isgalamido")
+	m.SetPlayerTeam(2, match.TeamRed, 0)
+
+	r := New(time.Now())
+	r.Add("ctf.log", []*match.Match{m})
+
+	if assert.Len(t, r.Matches, 1) {
+		assert.NotNil(t, r.Matches[0].Teams)
+		assert.NotNil(t, r.Matches[0].CTF)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	matches, err := parser.ParseLog("../parser/testfiles/qgames_three_matches.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New(time.Date(2024, 3, 9, 21, 0, 0, 0, time.UTC))
+	r.Add("three_matches.log", matches)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Write(&buf))
+	assert.True(t, json.Valid(buf.Bytes()))
+
+	got, err := Decode(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, r.Version, got.Version)
+	assert.Equal(t, r.Metadata, got.Metadata)
+	if assert.Len(t, got.Matches, 3) {
+		for i, want := range r.Matches {
+			gotMatch := got.Matches[i]
+
+			assert.Equal(t, want.Game, gotMatch.Game)
+			assert.Equal(t, want.Summary, gotMatch.Summary)
+			assert.Equal(t, want.Discrepancies, gotMatch.Discrepancies)
+			assert.Equal(t, want.Match.Index, gotMatch.Match.Index)
+			assert.Equal(t, want.Match.Settings, gotMatch.Match.Settings)
+			assert.Equal(t, want.Match.Duration, gotMatch.Match.Duration)
+			assert.Equal(t, want.Match.TotalKills, gotMatch.Match.TotalKills)
+			assert.Equal(t, want.Match.Kills, gotMatch.Match.Kills)
+			assert.Equal(t, want.Match.KillFeed, gotMatch.Match.KillFeed)
+			assert.Equal(t, want.Match.KillsByMeans, gotMatch.Match.KillsByMeans)
+			assert.Equal(t, want.Match.Roster, gotMatch.Match.Roster)
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "should fail on invalid json",
+			input:   "{",
+			wantErr: "decoding the report",
+		},
+		{
+			name:    "should fail on a newer version",
+			input:   `{"version": 2, "metadata": {}, "matches": []}`,
+			wantErr: "unsupported report version 2",
+		},
+		{
+			name:    "should fail on a document without a version",
+			input:   `{"matches": []}`,
+			wantErr: "unsupported report version 0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(tt.input))
+			assert.Nil(t, got)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log-parser/event"
+	"log-parser/match"
+	"log-parser/parser"
+	"slices"
+)
+
+const recordMatch = "match"
+
+type (
+	// eventStream writes newline-delimited JSON: one object per digested event
+	// of a match followed by one for the match itself. Every object carries its
+	// type, the log it was read from and the game it belongs to, next to the
+	// fields of the event or match.
+	eventStream struct {
+		w io.Writer
+	}
+)
+
+func newEventStream(w io.Writer) *eventStream {
+	return &eventStream{w: w}
+}
+
+// observer writes the matches of the log at source, and their events, as the
+// parser produces them, leaving out the matches the filters of o drop.
+func (s *eventStream) observer(o *options, source string) parser.MatchObserver {
+	return func(gameMatch *match.Match, events []event.Event) error {
+		if !o.keep(gameMatch) {
+			return nil
+		}
+
+		for _, e := range events {
+			if err := s.write(e.Type(), source, gameMatch.Index, e); err != nil {
+				return err
+			}
+		}
+
+		return s.write(recordMatch, source, gameMatch.Index, gameMatch)
+	}
+}
+
+func (s *eventStream) write(recordType, source string, game int, v any) error {
+	header, err := marshal(struct {
+		Type   string `json:"type"`
+		Source string `json:"source"`
+		Game   int    `json:"game"`
+	}{Type: recordType, Source: source, Game: game})
+	if err != nil {
+		return fmt.Errorf("marshalling %s record: %w", recordType, err)
+	}
+
+	body, err := marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshalling %s record: %w", recordType, err)
+	}
+
+	// the fields of the record follow its type, source and game in one object.
+	record := header
+	if len(body) > len("{}") {
+		record = append(append(header[:len(header)-1], ','), body[1:]...)
+	}
+
+	if _, err = s.w.Write(append(record, '\n')); err != nil {
+		return fmt.Errorf("writing %s record: %w", recordType, err)
+	}
+
+	return nil
+}
+
+// marshal encodes v as compact JSON, without escaping the HTML characters
+// found in names such as <world>.
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// streamLogs parses every input log, writing the stream of each match to w as
+// soon as it is complete.
+func streamLogs(o *options, w io.Writer) ([]parsedLog, error) {
+	opts, err := o.parserOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	stream := newEventStream(w)
+
+	logs := make([]parsedLog, 0, len(o.inputs))
+	for _, path := range o.inputs {
+		matches, err := parser.ParseLog(path, append(opts, parser.WithMatchObserver(stream.observer(o, path)))...)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, parsedLog{path: path, matches: slices.DeleteFunc(matches, func(gameMatch *match.Match) bool {
+			return !o.keep(gameMatch)
+		})})
+	}
+
+	return logs, nil
+}